@@ -0,0 +1,46 @@
+// Usage: go run generator.go crop /path/to/in.mdl /path/to/out.mdl
+// (or "empty" instead of "crop" to write an empty Model with the same
+// resolution, or "layout /path/to/in.txt /path/to/out.mdl" to convert an ASCII
+// layout of the slices of a Model as described for nmms.NewMatrixFromLayout).
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"nmms"
+)
+
+func readModel(path string) *nmms.Matrix {
+	fmt.Printf("Reading Model file \"%s\".\n", path)
+	var m nmms.Matrix
+	nmms.Check(m.ReadFromFile(path))
+	return &m
+}
+
+func main() {
+	if len(os.Args) < 4 {
+		nmms.ExitWithErrorMsg("Missing operation, input and output argument.")
+	}
+	var m *nmms.Matrix
+	var err error
+	switch os.Args[1] {
+	case "crop":
+		m, err = readModel(os.Args[2]).Crop()
+	case "empty":
+		m, err = nmms.NewMatrix(readModel(os.Args[2]).Resolution())
+	case "layout":
+		var l []byte
+		fmt.Printf("Reading layout file \"%s\".\n", os.Args[2])
+		if l, err = ioutil.ReadFile(os.Args[2]); err == nil {
+			m, err = nmms.NewMatrixFromLayout(string(l))
+		}
+	default:
+		err = fmt.Errorf("unknown operation \"%s\"", os.Args[1])
+	}
+	nmms.Check(err)
+	fmt.Printf("Writing Model file \"%s\" with resolution %d.\n", os.Args[3],
+		m.Resolution())
+	nmms.Check(m.WriteToFile(os.Args[3]))
+}
@@ -0,0 +1,33 @@
+// Usage: go run imager.go /path/to/file.mdl /path/to/out.png [view]
+// where view is one of "oblique" (the default), "front", "top" or "side".
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"nmms"
+)
+
+const (
+	imgWidth  = 1024
+	imgHeight = 768
+)
+
+func main() {
+	if len(os.Args) < 3 {
+		nmms.ExitWithErrorMsg("Missing Model and picture argument.")
+	}
+	view := nmms.ObliqueView
+	if len(os.Args) > 3 {
+		var err error
+		view, err = nmms.ParseView(os.Args[3])
+		nmms.Check(err)
+	}
+	var m nmms.Matrix
+	fmt.Printf("Reading Model file \"%s\".\n", os.Args[1])
+	nmms.Check(m.ReadFromFile(os.Args[1]))
+	fmt.Printf("Writing picture \"%s\".\n", os.Args[2])
+	nmms.Check(nmms.WriteImageFile(os.Args[2], &m, nil, view, imgWidth,
+		imgHeight))
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"nmms"
+	"nmms/viewer"
 )
 
 func main() {
@@ -30,10 +31,10 @@ func main() {
 	}
 	fmt.Printf("Filled=%d\n", numFilled)
 
-	var viewer nmms.Renderer
-	nmms.Check(viewer.Init(&nSys))
-	for viewer.Update(&nSys) {
+	var v viewer.Renderer
+	nmms.Check(v.Init(&nSys))
+	for v.Update(&nSys) {
 		time.Sleep(250 * time.Millisecond)
 	}
-	viewer.Quit()
+	v.Quit()
 }
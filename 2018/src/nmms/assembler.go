@@ -0,0 +1,195 @@
+package nmms
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	asmCommentChar = "#"
+)
+
+func parseCoord(s string) (Coordinate, error) {
+	var c Coordinate
+	var extra string
+	n, _ := fmt.Sscanf(s, "<%d,%d,%d>%s", &c.X, &c.Y, &c.Z, &extra)
+	if n != 3 {
+		return c, fmt.Errorf("malformed coordinate difference \"%s\"", s)
+	}
+	return c, nil
+}
+
+func parseLD(s string, maxLen int) (Coordinate, error) {
+	c, err := parseCoord(s)
+	if err != nil {
+		return c, err
+	}
+	if !isValidLD(c, maxLen) {
+		return c, fmt.Errorf("malformed linear coordinate difference %v", &c)
+	}
+	return c, nil
+}
+
+func parseND(s string) (Coordinate, error) {
+	c, err := parseCoord(s)
+	if err != nil {
+		return c, err
+	}
+	if !isValidND(c) {
+		return c, fmt.Errorf("malformed near coordinate difference %v", &c)
+	}
+	return c, nil
+}
+
+func parseFD(s string) (Coordinate, error) {
+	c, err := parseCoord(s)
+	if err != nil {
+		return c, err
+	}
+	if !isValidFD(c) {
+		return c, fmt.Errorf("malformed far coordinate difference %v", &c)
+	}
+	return c, nil
+}
+
+// ParseCommand parses a Command from its textual form, as produced by the
+// String() method of the Command (e.g. "SMove <0,0,5>").
+func ParseCommand(s string) (Command, error) {
+	f := strings.Fields(s)
+	if len(f) == 0 {
+		return nil, fmt.Errorf("missing Command")
+	}
+	numArgs := map[string]int{
+		"Halt": 0, "Wait": 0, "Flip": 0, "SMove": 1, "LMove": 2,
+		"FusionP": 1, "FusionS": 1, "Fission": 2, "Fill": 1, "Void": 1,
+		"GFill": 2, "GVoid": 2,
+	}
+	na, ok := numArgs[f[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown Command \"%s\"", f[0])
+	}
+	if len(f)-1 != na {
+		return nil, fmt.Errorf("%s needs %d arguments, but got %d", f[0], na,
+			len(f)-1)
+	}
+
+	var err error
+	switch f[0] {
+	case "Halt":
+		return new(HaltCmd), nil
+	case "Wait":
+		return new(WaitCmd), nil
+	case "Flip":
+		return new(FlipCmd), nil
+	case "SMove":
+		c := new(SMoveCmd)
+		c.LLD, err = parseLD(f[1], 15)
+		return c, err
+	case "LMove":
+		c := new(LMoveCmd)
+		if c.SLD1, err = parseLD(f[1], 5); err != nil {
+			return nil, err
+		}
+		c.SLD2, err = parseLD(f[2], 5)
+		return c, err
+	case "FusionP":
+		c := new(FusionPCmd)
+		c.ND, err = parseND(f[1])
+		return c, err
+	case "FusionS":
+		c := new(FusionSCmd)
+		c.ND, err = parseND(f[1])
+		return c, err
+	case "Fission":
+		c := new(FissionCmd)
+		if c.ND, err = parseND(f[1]); err != nil {
+			return nil, err
+		}
+		if c.M, err = strconv.Atoi(f[2]); err != nil {
+			return nil, err
+		}
+		if c.M < 0 || c.M > 255 {
+			return nil, fmt.Errorf("bad seed-count %d for Fission", c.M)
+		}
+		return c, nil
+	case "Fill":
+		c := new(FillCmd)
+		c.ND, err = parseND(f[1])
+		return c, err
+	case "Void":
+		c := new(VoidCmd)
+		c.ND, err = parseND(f[1])
+		return c, err
+	case "GFill":
+		c := new(GFillCmd)
+		if c.ND, err = parseND(f[1]); err != nil {
+			return nil, err
+		}
+		c.FD, err = parseFD(f[2])
+		return c, err
+	default:
+		c := new(GVoidCmd)
+		if c.ND, err = parseND(f[1]); err != nil {
+			return nil, err
+		}
+		c.FD, err = parseFD(f[2])
+		return c, err
+	}
+}
+
+// Assemble parses Commands, one per line, from the given textual form of a
+// Trace. Blank lines and comments starting with '#' are ignored.
+func Assemble(r io.Reader) ([]Command, error) {
+	var cmds []Command
+	s := bufio.NewScanner(r)
+	for ln := 1; s.Scan(); ln++ {
+		l := s.Text()
+		if i := strings.Index(l, asmCommentChar); i >= 0 {
+			l = l[:i]
+		}
+		if len(strings.TrimSpace(l)) == 0 {
+			continue
+		}
+		c, err := ParseCommand(l)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", ln, err)
+		}
+		cmds = append(cmds, c)
+	}
+	return cmds, s.Err()
+}
+
+// AssembleFile converts the textual form of a Trace in the given file into
+// the binary form of the Trace in the other given file.
+func AssembleFile(asmPath, trcPath string) error {
+	f, err := os.Open(asmPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cmds, err := Assemble(f)
+	if err != nil {
+		return fmt.Errorf("\"%s\": %v", asmPath, err)
+	}
+	return WriteTraceFile(trcPath, cmds)
+}
+
+// Disassemble writes the textual form of the Commands in the given binary
+// encoding of a Trace, one per line.
+func Disassemble(w io.Writer, encCmds []byte) error {
+	for off := 0; off < len(encCmds); {
+		c, n, err := DecodeNextCommand(encCmds[off:])
+		if err != nil {
+			return fmt.Errorf("byte %d: %v", off, err)
+		}
+		if _, err = fmt.Fprintln(w, c); err != nil {
+			return err
+		}
+		off += n
+	}
+	return nil
+}
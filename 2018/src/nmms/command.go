@@ -2,14 +2,27 @@ package nmms
 
 import (
 	"fmt"
+	"sort"
 )
 
 type Command interface {
 	Execute(n *NmmSystem, bIdx int) error
+	volatileCoords(n *NmmSystem, bIdx int) []Coordinate
 	decode(encCmds []byte) (int, error)
+	// Encode returns the binary encoding of the Command used in Trace files.
+	Encode() []byte
 	fmt.Stringer
 }
 
+// EncodeCommands returns the binary encoding of the given Commands.
+func EncodeCommands(cmds []Command) []byte {
+	var encCmds []byte
+	for _, c := range cmds {
+		encCmds = append(encCmds, c.Encode()...)
+	}
+	return encCmds
+}
+
 func DecodeNextCommand(encCmds []byte) (Command, int, error) {
 	if len(encCmds) <= 0 {
 		return nil, 0, fmt.Errorf("premature end of Command-stream")
@@ -69,10 +82,18 @@ func (h *HaltCmd) Execute(n *NmmSystem, bIdx int) error {
 	return nil
 }
 
+func (h *HaltCmd) volatileCoords(n *NmmSystem, bIdx int) []Coordinate {
+	return []Coordinate{n.Bots[bIdx].Pos}
+}
+
 func (h *HaltCmd) decode(encCmds []byte) (int, error) {
 	return 1, nil
 }
 
+func (h *HaltCmd) Encode() []byte {
+	return []byte{0xFF}
+}
+
 func (h *HaltCmd) String() string {
 	return "Halt"
 }
@@ -85,10 +106,18 @@ func (w *WaitCmd) Execute(n *NmmSystem, bIdx int) error {
 	return nil
 }
 
+func (w *WaitCmd) volatileCoords(n *NmmSystem, bIdx int) []Coordinate {
+	return []Coordinate{n.Bots[bIdx].Pos}
+}
+
 func (w *WaitCmd) decode(encCmds []byte) (int, error) {
 	return 1, nil
 }
 
+func (w *WaitCmd) Encode() []byte {
+	return []byte{0xFE}
+}
+
 func (w *WaitCmd) String() string {
 	return "Wait"
 }
@@ -106,18 +135,82 @@ func (f *FlipCmd) Execute(n *NmmSystem, bIdx int) error {
 	return nil
 }
 
+func (f *FlipCmd) volatileCoords(n *NmmSystem, bIdx int) []Coordinate {
+	return []Coordinate{n.Bots[bIdx].Pos}
+}
+
 func (f *FlipCmd) decode(encCmds []byte) (int, error) {
 	return 1, nil
 }
 
+func (f *FlipCmd) Encode() []byte {
+	return []byte{0xFD}
+}
+
 func (f *FlipCmd) String() string {
 	return "Flip"
 }
 
-func mLen(c *Coordinate) int {
+// MLen returns the Manhattan length of a coordinate difference.
+func MLen(c *Coordinate) int {
 	return iAbs(c.X) + iAbs(c.Y) + iAbs(c.Z)
 }
 
+// fromLD returns the axis and the integer encoding a linear coordinate
+// difference, given the maximum length of such a difference.
+func fromLD(c Coordinate, maxLen int) (byte, int) {
+	switch {
+	case c.Y != 0:
+		return 0x2, c.Y + maxLen
+	case c.Z != 0:
+		return 0x3, c.Z + maxLen
+	default:
+		return 0x1, c.X + maxLen
+	}
+}
+
+// isValidLD checks whether the Coordinate is a linear coordinate difference
+// with a length of at most maxLen.
+func isValidLD(c Coordinate, maxLen int) bool {
+	numAxes := 0
+	for _, d := range []int{c.X, c.Y, c.Z} {
+		if d != 0 {
+			numAxes++
+		}
+	}
+	return numAxes == 1 && MLen(&c) <= maxLen
+}
+
+// moveRegion returns the Coordinates covered by a move from the given start
+// along each of the given linear coordinate differences in turn, including
+// the start.
+func moveRegion(start Coordinate, lds ...Coordinate) []Coordinate {
+	region := []Coordinate{start}
+	c := start
+	for _, ld := range lds {
+		step := Coordinate{iSign(ld.X), iSign(ld.Y), iSign(ld.Z)}
+		for i := MLen(&ld); i > 0; i-- {
+			c = c.Add(&step)
+			region = append(region, c)
+		}
+	}
+	return region
+}
+
+// checkMoveRegion verifies that the Coordinates covered by a move, except the
+// start, are within the Matrix and are not Full.
+func checkMoveRegion(n *NmmSystem, region []Coordinate) error {
+	for _, c := range region[1:] {
+		if !n.Mat.IsValidCoord(c) {
+			return fmt.Errorf("path leaves the Matrix at %v", &c)
+		}
+		if n.Mat.IsFull(c.X, c.Y, c.Z) {
+			return fmt.Errorf("path is blocked by a Full voxel at %v", &c)
+		}
+	}
+	return nil
+}
+
 /* SMove */
 
 type SMoveCmd struct {
@@ -125,8 +218,14 @@ type SMoveCmd struct {
 }
 
 func (s *SMoveCmd) Execute(n *NmmSystem, bIdx int) error {
+	if !isValidLD(s.LLD, 15) {
+		return fmt.Errorf("SMove by %v is not a long linear move", &s.LLD)
+	}
+	if err := checkMoveRegion(n, s.volatileCoords(n, bIdx)); err != nil {
+		return fmt.Errorf("SMove %v", err)
+	}
 	n.Bots[bIdx].Pos = n.Bots[bIdx].Pos.Add(&s.LLD)
-	n.Energy += 2 * mLen(&s.LLD)
+	n.Energy += 2 * MLen(&s.LLD)
 	return nil
 }
 
@@ -145,6 +244,10 @@ func toLLD(axis byte, integer int) (Coordinate, error) {
 	return coord, nil
 }
 
+func (s *SMoveCmd) volatileCoords(n *NmmSystem, bIdx int) []Coordinate {
+	return moveRegion(n.Bots[bIdx].Pos, s.LLD)
+}
+
 func (s *SMoveCmd) decode(encCmds []byte) (int, error) {
 	if len(encCmds) < 2 {
 		return 0, fmt.Errorf("premature end of Command-stream for SMove")
@@ -159,6 +262,11 @@ func (s *SMoveCmd) decode(encCmds []byte) (int, error) {
 	return 2, nil
 }
 
+func (s *SMoveCmd) Encode() []byte {
+	axis, integer := fromLD(s.LLD, 15)
+	return []byte{axis<<4 | 0x04, byte(integer)}
+}
+
 func (s *SMoveCmd) String() string {
 	return fmt.Sprintf("SMove %v", &s.LLD)
 }
@@ -186,10 +294,21 @@ type LMoveCmd struct {
 }
 
 func (l *LMoveCmd) Execute(n *NmmSystem, bIdx int) error {
+	if !isValidLD(l.SLD1, 5) || !isValidLD(l.SLD2, 5) {
+		return fmt.Errorf("LMove by %v and %v is not two short linear moves",
+			&l.SLD1, &l.SLD2)
+	}
+	if err := checkMoveRegion(n, l.volatileCoords(n, bIdx)); err != nil {
+		return fmt.Errorf("LMove %v", err)
+	}
 	n.Bots[bIdx].Pos = n.Bots[bIdx].Pos.Add(&l.SLD1)
 	n.Bots[bIdx].Pos = n.Bots[bIdx].Pos.Add(&l.SLD2)
-	n.Energy += 2 * (mLen(&l.SLD1) + 2 + mLen(&l.SLD2))
-	return fmt.Errorf("unimplemented %v", l)
+	n.Energy += 2 * (MLen(&l.SLD1) + 2 + MLen(&l.SLD2))
+	return nil
+}
+
+func (l *LMoveCmd) volatileCoords(n *NmmSystem, bIdx int) []Coordinate {
+	return moveRegion(n.Bots[bIdx].Pos, l.SLD1, l.SLD2)
 }
 
 func (l *LMoveCmd) decode(encCmds []byte) (int, error) {
@@ -212,6 +331,12 @@ func (l *LMoveCmd) decode(encCmds []byte) (int, error) {
 	return 2, nil
 }
 
+func (l *LMoveCmd) Encode() []byte {
+	axis1, integer1 := fromLD(l.SLD1, 5)
+	axis2, integer2 := fromLD(l.SLD2, 5)
+	return []byte{axis2<<6 | axis1<<4 | 0x0C, byte(integer2<<4 | integer1)}
+}
+
 func (l *LMoveCmd) String() string {
 	return fmt.Sprintf("LMove %v %v", &l.SLD1, &l.SLD2)
 }
@@ -246,13 +371,68 @@ func toND(integer int) (Coordinate, error) {
 	return coord, nil
 }
 
+func fromND(c Coordinate) byte {
+	return byte((c.X+1)*9 + (c.Y+1)*3 + (c.Z + 1))
+}
+
 type FusionPCmd struct {
 	ND Coordinate
 }
 
+// findFusionPartner returns the index of the Nanobot at the given near
+// coordinate difference from the given Nanobot, verifying that it is executing
+// the complementary fusion Command pointing back at the given Nanobot.
+func findFusionPartner(n *NmmSystem, bIdx int, nd *Coordinate,
+	wantPrimary bool) (int, error) {
+	c := n.Bots[bIdx].Pos
+	pc := c.Add(nd)
+	pIdx := n.botAt(pc)
+	if pIdx < 0 {
+		return -1, fmt.Errorf("no Nanobot at %v to fuse with", &pc)
+	}
+	pBid := n.Bots[pIdx].Bid
+	var pnd Coordinate
+	switch pCmd := n.cmds[pIdx].(type) {
+	case *FusionPCmd:
+		if !wantPrimary {
+			return -1, fmt.Errorf(
+				"Nanobot %d at %v is also doing FusionP", pBid, &pc)
+		}
+		pnd = pCmd.ND
+	case *FusionSCmd:
+		if wantPrimary {
+			return -1, fmt.Errorf(
+				"Nanobot %d at %v is also doing FusionS", pBid, &pc)
+		}
+		pnd = pCmd.ND
+	default:
+		return -1, fmt.Errorf("Nanobot %d at %v is doing %v instead of fusing",
+			pBid, &pc, n.cmds[pIdx])
+	}
+	if back := pc.Add(&pnd); back != c {
+		return -1, fmt.Errorf(
+			"Nanobot %d at %v fuses with %v instead of Nanobot %d at %v",
+			pBid, &pc, &back, n.Bots[bIdx].Bid, &c)
+	}
+	return pIdx, nil
+}
+
 func (f *FusionPCmd) Execute(n *NmmSystem, bIdx int) error {
-	// TODO: Implement this.
-	return fmt.Errorf("unimplemented %v", f)
+	sIdx, err := findFusionPartner(n, bIdx, &f.ND, false)
+	if err != nil {
+		return err
+	}
+	p, s := &n.Bots[bIdx], &n.Bots[sIdx]
+	p.Seeds = append(p.Seeds, s.Bid)
+	p.Seeds = append(p.Seeds, s.Seeds...)
+	sort.Ints(p.Seeds)
+	n.removeBot(sIdx)
+	n.Energy -= 24
+	return nil
+}
+
+func (f *FusionPCmd) volatileCoords(n *NmmSystem, bIdx int) []Coordinate {
+	return []Coordinate{n.Bots[bIdx].Pos}
 }
 
 func (f *FusionPCmd) decode(encCmds []byte) (int, error) {
@@ -265,6 +445,10 @@ func (f *FusionPCmd) decode(encCmds []byte) (int, error) {
 	return 1, nil
 }
 
+func (f *FusionPCmd) Encode() []byte {
+	return []byte{fromND(f.ND)<<3 | 0x07}
+}
+
 func (f *FusionPCmd) String() string {
 	return fmt.Sprintf("FusionP %v", &f.ND)
 }
@@ -276,8 +460,13 @@ type FusionSCmd struct {
 }
 
 func (f *FusionSCmd) Execute(n *NmmSystem, bIdx int) error {
-	// TODO: Implement this.
-	return fmt.Errorf("unimplemented %v", f)
+	// The primary Nanobot does the actual work of fusing the two Nanobots.
+	_, err := findFusionPartner(n, bIdx, &f.ND, true)
+	return err
+}
+
+func (f *FusionSCmd) volatileCoords(n *NmmSystem, bIdx int) []Coordinate {
+	return []Coordinate{n.Bots[bIdx].Pos}
 }
 
 func (f *FusionSCmd) decode(encCmds []byte) (int, error) {
@@ -290,6 +479,10 @@ func (f *FusionSCmd) decode(encCmds []byte) (int, error) {
 	return 1, nil
 }
 
+func (f *FusionSCmd) Encode() []byte {
+	return []byte{fromND(f.ND)<<3 | 0x06}
+}
+
 func (f *FusionSCmd) String() string {
 	return fmt.Sprintf("FusionS %v", &f.ND)
 }
@@ -302,8 +495,33 @@ type FissionCmd struct {
 }
 
 func (f *FissionCmd) Execute(n *NmmSystem, bIdx int) error {
-	// TODO: Implement this.
-	return fmt.Errorf("unimplemented %v", f)
+	b := &n.Bots[bIdx]
+	numSeeds := len(b.Seeds)
+	if numSeeds == 0 {
+		return fmt.Errorf("Fission requires the Nanobot to have seeds")
+	}
+	if f.M+1 > numSeeds {
+		return fmt.Errorf("Fission needs %d seeds, but only %d are left",
+			f.M+1, numSeeds)
+	}
+	c := b.Pos.Add(&f.ND)
+	if !n.Mat.IsValidCoord(c) {
+		return fmt.Errorf("Fission target %v is out of bounds", &c)
+	}
+	if n.Mat.IsFull(c.X, c.Y, c.Z) {
+		return fmt.Errorf("Fission target %v is Full", &c)
+	}
+	nb := Nanobot{Bid: b.Seeds[0], Pos: c}
+	nb.Seeds = append([]int{}, b.Seeds[1:f.M+1]...)
+	b.Seeds = b.Seeds[f.M+1:]
+	n.addBot(nb)
+	n.Energy += 24
+	return nil
+}
+
+func (f *FissionCmd) volatileCoords(n *NmmSystem, bIdx int) []Coordinate {
+	c := n.Bots[bIdx].Pos
+	return []Coordinate{c, c.Add(&f.ND)}
 }
 
 func (f *FissionCmd) decode(encCmds []byte) (int, error) {
@@ -320,6 +538,10 @@ func (f *FissionCmd) decode(encCmds []byte) (int, error) {
 	return 2, nil
 }
 
+func (f *FissionCmd) Encode() []byte {
+	return []byte{fromND(f.ND)<<3 | 0x05, byte(f.M)}
+}
+
 func (f *FissionCmd) String() string {
 	return fmt.Sprintf("Fission %v %d", &f.ND, f.M)
 }
@@ -332,10 +554,23 @@ type FillCmd struct {
 
 func (f *FillCmd) Execute(n *NmmSystem, bIdx int) error {
 	c := n.Bots[bIdx].Pos.Add(&f.ND)
-	n.Mat.SetFull(c.X, c.Y, c.Z)
+	if !n.Mat.IsValidCoord(c) {
+		return fmt.Errorf("Fill target %v is out of bounds", &c)
+	}
+	if n.Mat.IsFull(c.X, c.Y, c.Z) {
+		n.Energy += 6
+		return nil
+	}
+	n.fill(c)
+	n.Energy += 12
 	return nil
 }
 
+func (f *FillCmd) volatileCoords(n *NmmSystem, bIdx int) []Coordinate {
+	c := n.Bots[bIdx].Pos
+	return []Coordinate{c, c.Add(&f.ND)}
+}
+
 func (f *FillCmd) decode(encCmds []byte) (int, error) {
 	integer := int((encCmds[0] & 0xF8) >> 3)
 	var err error
@@ -346,17 +581,37 @@ func (f *FillCmd) decode(encCmds []byte) (int, error) {
 	return 1, nil
 }
 
+func (f *FillCmd) Encode() []byte {
+	return []byte{fromND(f.ND)<<3 | 0x03}
+}
+
 func (f *FillCmd) String() string {
 	return fmt.Sprintf("Fill %v", &f.ND)
 }
 
+/* Void */
+
 type VoidCmd struct {
 	ND Coordinate
 }
 
 func (v *VoidCmd) Execute(n *NmmSystem, bIdx int) error {
-	// TODO: Implement this.
-	return fmt.Errorf("unimplemented %v", v)
+	c := n.Bots[bIdx].Pos.Add(&v.ND)
+	if !n.Mat.IsValidCoord(c) {
+		return fmt.Errorf("Void target %v is out of bounds", &c)
+	}
+	if !n.Mat.IsFull(c.X, c.Y, c.Z) {
+		n.Energy += 3
+		return nil
+	}
+	n.void(c)
+	n.Energy -= 12
+	return nil
+}
+
+func (v *VoidCmd) volatileCoords(n *NmmSystem, bIdx int) []Coordinate {
+	c := n.Bots[bIdx].Pos
+	return []Coordinate{c, c.Add(&v.ND)}
 }
 
 func (v *VoidCmd) decode(encCmds []byte) (int, error) {
@@ -369,20 +624,152 @@ func (v *VoidCmd) decode(encCmds []byte) (int, error) {
 	return 1, nil
 }
 
+func (v *VoidCmd) Encode() []byte {
+	return []byte{fromND(v.ND)<<3 | 0x02}
+}
+
 func (v *VoidCmd) String() string {
 	return fmt.Sprintf("Void %v", &v.ND)
 }
 
 /* GFill */
 
+// groupCmd is implemented by the Commands that are executed jointly by a group
+// of Nanobots on a shared region of the Matrix.
+type groupCmd interface {
+	Command
+	// operands returns the near and far coordinate differences for the
+	// Command and whether it fills (instead of voids) the region.
+	operands() (Coordinate, Coordinate, bool)
+}
+
+func isValidFD(c Coordinate) bool {
+	cLen := IMax(iAbs(c.X), IMax(iAbs(c.Y), iAbs(c.Z)))
+	return cLen > 0 && cLen <= 30
+}
+
+func decodeFD(encCmds []byte) (Coordinate, error) {
+	coord := Coordinate{
+		int(encCmds[0]) - 30, int(encCmds[1]) - 30, int(encCmds[2]) - 30}
+	if !isValidFD(coord) {
+		return coord, fmt.Errorf("malformed far coordinate difference %v",
+			&coord)
+	}
+	return coord, nil
+}
+
+// groupRegion returns the minimum and maximum Coordinates of the region that a
+// Nanobot's group-Command operates on.
+func groupRegion(n *NmmSystem, bIdx int, g groupCmd) (Coordinate, Coordinate) {
+	nd, fd, _ := g.operands()
+	c0 := n.Bots[bIdx].Pos.Add(&nd)
+	c1 := c0.Add(&fd)
+	return Coordinate{iMin(c0.X, c1.X), iMin(c0.Y, c1.Y), iMin(c0.Z, c1.Z)},
+		Coordinate{IMax(c0.X, c1.X), IMax(c0.Y, c1.Y), IMax(c0.Z, c1.Z)}
+}
+
+func inRegion(c, rMin, rMax Coordinate) bool {
+	return c.X >= rMin.X && c.X <= rMax.X && c.Y >= rMin.Y && c.Y <= rMax.Y &&
+		c.Z >= rMin.Z && c.Z <= rMax.Z
+}
+
+func groupVolatileCoords(n *NmmSystem, bIdx int, g groupCmd) []Coordinate {
+	rMin, rMax := groupRegion(n, bIdx, g)
+	vc := []Coordinate{n.Bots[bIdx].Pos}
+	for x := rMin.X; x <= rMax.X; x++ {
+		for y := rMin.Y; y <= rMax.Y; y++ {
+			for z := rMin.Z; z <= rMax.Z; z++ {
+				vc = append(vc, Coordinate{x, y, z})
+			}
+		}
+	}
+	return vc
+}
+
+// executeGroup executes a group-Command once for the entire group, when it is
+// invoked for the first Nanobot in the group.
+func executeGroup(n *NmmSystem, bIdx int, g groupCmd) error {
+	if n.groupLeader(n.cmds, bIdx) != bIdx {
+		return nil
+	}
+	rMin, rMax := groupRegion(n, bIdx, g)
+	if !n.Mat.IsValidCoord(rMin) || !n.Mat.IsValidCoord(rMax) {
+		return fmt.Errorf("region [%v, %v] is out of bounds", &rMin, &rMax)
+	}
+	dim := 0
+	if rMin.X != rMax.X {
+		dim++
+	}
+	if rMin.Y != rMax.Y {
+		dim++
+	}
+	if rMin.Z != rMax.Z {
+		dim++
+	}
+
+	corners := make(map[Coordinate]int)
+	for i := bIdx; i < len(n.cmds); i++ {
+		if n.groupLeader(n.cmds, i) != bIdx {
+			continue
+		}
+		nd, _, _ := n.cmds[i].(groupCmd).operands()
+		c := n.Bots[i].Pos.Add(&nd)
+		if j, ok := corners[c]; ok {
+			return fmt.Errorf(
+				"corner %v of region [%v, %v] claimed by Nanobots %d and %d",
+				&c, &rMin, &rMax, n.Bots[j].Bid, n.Bots[i].Bid)
+		}
+		corners[c] = i
+	}
+	if len(corners) != 1<<uint(dim) {
+		return fmt.Errorf("region [%v, %v] needs %d Nanobots, but has %d",
+			&rMin, &rMax, 1<<uint(dim), len(corners))
+	}
+	for _, b := range n.Bots {
+		if inRegion(b.Pos, rMin, rMax) {
+			return fmt.Errorf("region [%v, %v] contains Nanobot %d at %v",
+				&rMin, &rMax, b.Bid, &b.Pos)
+		}
+	}
+
+	_, _, fill := g.operands()
+	for x := rMin.X; x <= rMax.X; x++ {
+		for y := rMin.Y; y <= rMax.Y; y++ {
+			for z := rMin.Z; z <= rMax.Z; z++ {
+				full := n.Mat.IsFull(x, y, z)
+				switch {
+				case fill && full:
+					n.Energy += 6
+				case fill:
+					n.fill(Coordinate{x, y, z})
+					n.Energy += 12
+				case full:
+					n.void(Coordinate{x, y, z})
+					n.Energy -= 12
+				default:
+					n.Energy += 3
+				}
+			}
+		}
+	}
+	return nil
+}
+
 type GFillCmd struct {
 	ND Coordinate
 	FD Coordinate
 }
 
 func (g *GFillCmd) Execute(n *NmmSystem, bIdx int) error {
-	// TODO: Implement this.
-	return fmt.Errorf("unimplemented %v", g)
+	return executeGroup(n, bIdx, g)
+}
+
+func (g *GFillCmd) operands() (Coordinate, Coordinate, bool) {
+	return g.ND, g.FD, true
+}
+
+func (g *GFillCmd) volatileCoords(n *NmmSystem, bIdx int) []Coordinate {
+	return groupVolatileCoords(n, bIdx, g)
 }
 
 func (g *GFillCmd) decode(encCmds []byte) (int, error) {
@@ -395,12 +782,18 @@ func (g *GFillCmd) decode(encCmds []byte) (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	g.FD.X = int(encCmds[1])
-	g.FD.Y = int(encCmds[2])
-	g.FD.Z = int(encCmds[3])
+	g.FD, err = decodeFD(encCmds[1:4])
+	if err != nil {
+		return 0, err
+	}
 	return 4, nil
 }
 
+func (g *GFillCmd) Encode() []byte {
+	return []byte{fromND(g.ND)<<3 | 0x01, byte(g.FD.X + 30), byte(g.FD.Y + 30),
+		byte(g.FD.Z + 30)}
+}
+
 func (g *GFillCmd) String() string {
 	return fmt.Sprintf("GFill %v %v", &g.ND, &g.FD)
 }
@@ -413,8 +806,15 @@ type GVoidCmd struct {
 }
 
 func (g *GVoidCmd) Execute(n *NmmSystem, bIdx int) error {
-	// TODO: Implement this.
-	return fmt.Errorf("unimplemented %v", g)
+	return executeGroup(n, bIdx, g)
+}
+
+func (g *GVoidCmd) operands() (Coordinate, Coordinate, bool) {
+	return g.ND, g.FD, false
+}
+
+func (g *GVoidCmd) volatileCoords(n *NmmSystem, bIdx int) []Coordinate {
+	return groupVolatileCoords(n, bIdx, g)
 }
 
 func (g *GVoidCmd) decode(encCmds []byte) (int, error) {
@@ -427,12 +827,18 @@ func (g *GVoidCmd) decode(encCmds []byte) (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	g.FD.X = int(encCmds[1])
-	g.FD.Y = int(encCmds[2])
-	g.FD.Z = int(encCmds[3])
+	g.FD, err = decodeFD(encCmds[1:4])
+	if err != nil {
+		return 0, err
+	}
 	return 4, nil
 }
 
+func (g *GVoidCmd) Encode() []byte {
+	return []byte{fromND(g.ND)<<3 | 0x00, byte(g.FD.X + 30), byte(g.FD.Y + 30),
+		byte(g.FD.Z + 30)}
+}
+
 func (g *GVoidCmd) String() string {
 	return fmt.Sprintf("GVoid %v %v", &g.ND, &g.FD)
 }
@@ -0,0 +1,227 @@
+package nmms
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The number of time-steps between snapshots of the system.
+	snapshotInterval = 1024
+)
+
+const debuggerHelp = `Commands:
+  s [N]       Step forward by N (default 1) time-steps.
+  r [N]       Step backward by N (default 1) time-steps.
+  c [N]       Continue until time-step N, a breakpoint or the end.
+  b cmd NAME  Break before a time-step executing a Command named NAME.
+  b bot ID    Break before a time-step where Nanobot ID does not Wait.
+  d           Delete all breakpoints.
+  p           Print the state of the system.
+  q           Quit.
+`
+
+// Debugger allows stepping forwards and backwards through the execution of a
+// Trace. Stepping backwards restores the closest earlier snapshot of the
+// system and executes time-steps from there.
+type Debugger struct {
+	sys       *NmmSystem
+	snapshots []*NmmSystem
+	cmdBreaks map[string]bool
+	botBreaks map[int]bool
+	lastErr   error
+	w         io.Writer
+}
+
+func NewDebugger(n *NmmSystem) *Debugger {
+	return &Debugger{
+		sys:       n,
+		snapshots: []*NmmSystem{n.Copy()},
+		cmdBreaks: make(map[string]bool),
+		botBreaks: make(map[int]bool),
+	}
+}
+
+// Run reads debugger-commands from r until it ends or a "q" is seen,
+// writing the output to w.
+func (d *Debugger) Run(r io.Reader, w io.Writer) error {
+	d.w = w
+	s := bufio.NewScanner(r)
+	d.printState()
+	for {
+		fmt.Fprintf(w, "(step %d) ", d.sys.Step)
+		if !s.Scan() {
+			break
+		}
+		f := strings.Fields(s.Text())
+		if len(f) == 0 {
+			continue
+		}
+		if f[0] == "q" {
+			return nil
+		}
+		if err := d.execute(f); err != nil {
+			fmt.Fprintf(w, "%v\n", err)
+		}
+	}
+	return s.Err()
+}
+
+func (d *Debugger) execute(f []string) error {
+	arg := -1
+	if len(f) > 1 && f[0] != "b" {
+		var err error
+		if arg, err = strconv.Atoi(f[1]); err != nil || arg < 0 {
+			return fmt.Errorf("bad argument \"%s\"", f[1])
+		}
+	}
+	switch f[0] {
+	case "s":
+		if arg < 0 {
+			arg = 1
+		}
+		for i := 0; i < arg && d.stepForward(); i++ {
+		}
+		d.printState()
+	case "r":
+		if arg < 0 {
+			arg = 1
+		}
+		if err := d.restore(IMax(d.sys.Step-arg, 0)); err != nil {
+			return err
+		}
+		d.printState()
+	case "c":
+		if arg >= 0 && arg < d.sys.Step {
+			if err := d.restore(arg); err != nil {
+				return err
+			}
+		} else {
+			for (arg < 0 || d.sys.Step < arg) && d.stepForward() {
+				if d.atBreakpoint() {
+					fmt.Fprintf(d.w, "Breakpoint at step %d.\n", d.sys.Step)
+					break
+				}
+			}
+		}
+		d.printState()
+	case "b":
+		return d.addBreakpoint(f)
+	case "d":
+		d.cmdBreaks = make(map[string]bool)
+		d.botBreaks = make(map[int]bool)
+	case "p":
+		d.printState()
+	default:
+		fmt.Fprint(d.w, debuggerHelp)
+	}
+	return nil
+}
+
+func (d *Debugger) addBreakpoint(f []string) error {
+	if len(f) != 3 {
+		return fmt.Errorf("usage: b cmd NAME | b bot ID")
+	}
+	switch f[1] {
+	case "cmd":
+		d.cmdBreaks[f[2]] = true
+	case "bot":
+		bid, err := strconv.Atoi(f[2])
+		if err != nil {
+			return fmt.Errorf("bad Nanobot ID \"%s\"", f[2])
+		}
+		d.botBreaks[bid] = true
+	default:
+		return fmt.Errorf("unknown breakpoint-type \"%s\"", f[1])
+	}
+	return nil
+}
+
+// atBreakpoint checks whether the next time-step triggers a breakpoint.
+func (d *Debugger) atBreakpoint() bool {
+	cmds, err := d.sys.Trc.PeekCommands(len(d.sys.Bots))
+	if err != nil {
+		return false
+	}
+	for i, c := range cmds {
+		if d.cmdBreaks[CmdName(c)] {
+			return true
+		}
+		if _, ok := c.(*WaitCmd); !ok && d.botBreaks[d.sys.Bots[i].Bid] {
+			return true
+		}
+	}
+	return false
+}
+
+// stepForward executes a time-step and reports whether it succeeded. On an
+// error, the system is restored to its state before the time-step.
+func (d *Debugger) stepForward() bool {
+	if len(d.sys.Bots) == 0 || d.sys.Trc.IsEmpty() {
+		fmt.Fprintf(d.w, "Trace has ended.\n")
+		return false
+	}
+	step := d.sys.Step
+	if err := d.sys.ExecuteStep(); err != nil {
+		d.lastErr = err
+		fmt.Fprintf(d.w, "ERROR: %v\n", err)
+		if err = d.restore(step); err != nil {
+			fmt.Fprintf(d.w, "%v\n", err)
+		}
+		return false
+	}
+	d.lastErr = nil
+	last := d.snapshots[len(d.snapshots)-1]
+	if d.sys.Step >= last.Step+snapshotInterval {
+		d.snapshots = append(d.snapshots, d.sys.Copy())
+	}
+	return true
+}
+
+// restore sets the system to its state at the start of the given time-step,
+// which must not be after the current time-step. The system is left as it is
+// if the time-steps from the closest earlier snapshot cannot be replayed, e.g.
+// because the Trace cannot be read again.
+func (d *Debugger) restore(step int) error {
+	i := sort.Search(len(d.snapshots), func(i int) bool {
+		return d.snapshots[i].Step > step
+	}) - 1
+	n := d.snapshots[i].Copy()
+	for n.Step < step {
+		if err := n.ExecuteStep(); err != nil {
+			return fmt.Errorf("cannot go back to step %d: replay of step %d "+
+				"failed: %v", step, n.Step, err)
+		}
+	}
+	*d.sys = *n
+	return nil
+}
+
+func (d *Debugger) printState() {
+	n := d.sys
+	harmonics := "Low"
+	if n.HighHarmonics {
+		harmonics = "High"
+	}
+	fmt.Fprintf(d.w, "Step: %d, Energy: %d, Harmonics: %s, Nanobots: %d\n",
+		n.Step, n.Energy, harmonics, len(n.Bots))
+	cmds, err := n.Trc.PeekCommands(len(n.Bots))
+	for i, b := range n.Bots {
+		next := ""
+		if err == nil && i < len(cmds) {
+			next = cmds[i].String()
+		}
+		fmt.Fprintf(d.w, "  Nanobot %d at %v with %d seeds: %s\n", b.Bid,
+			&b.Pos, len(b.Seeds), next)
+	}
+	if err != nil {
+		fmt.Fprintf(d.w, "  Next Commands: %v\n", err)
+	}
+	if d.lastErr != nil {
+		fmt.Fprintf(d.w, "  Failed: %v\n", d.lastErr)
+	}
+}
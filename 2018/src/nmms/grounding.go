@@ -0,0 +1,214 @@
+package nmms
+
+// groundTracker keeps track of whether the Full voxels of a Matrix are
+// grounded, i.e. connected to the floor (y = 0) via other Full voxels. It
+// uses a disjoint-set forest with an extra element representing the floor.
+// Filling a voxel just merges sets. Voiding one can split a set, so when every
+// Full voxel is grounded, the voided voxel is left in the set of the floor as
+// a "ghost" if a search from each of its Full neighbours still reaches the
+// floor. Otherwise the forest is marked as stale and it is rebuilt the next
+// time it is needed.
+type groundTracker struct {
+	res     int
+	parent  []int32
+	size    []int32
+	ghost   []bool
+	numFull int
+	ghosts  int
+	stale   bool
+}
+
+func (g *groundTracker) index(x, y, z int) int32 {
+	return int32(x*g.res*g.res + y*g.res + z)
+}
+
+func (g *groundTracker) floor() int32 {
+	return int32(g.res * g.res * g.res)
+}
+
+func (g *groundTracker) find(i int32) int32 {
+	for g.parent[i] != i {
+		g.parent[i] = g.parent[g.parent[i]]
+		i = g.parent[i]
+	}
+	return i
+}
+
+func (g *groundTracker) union(i, j int32) {
+	ri, rj := g.find(i), g.find(j)
+	if ri == rj {
+		return
+	}
+	if g.size[ri] < g.size[rj] {
+		ri, rj = rj, ri
+	}
+	g.parent[rj] = ri
+	g.size[ri] += g.size[rj]
+}
+
+// connect merges the set for a newly-Full voxel with those of its Full
+// neighbours and with the floor, if it is on the floor.
+func (g *groundTracker) connect(m *Matrix, x, y, z int) {
+	i := g.index(x, y, z)
+	if y == 0 {
+		g.union(i, g.floor())
+	}
+	for _, c := range fullNbrs(m, x, y, z) {
+		g.union(i, g.index(c.X, c.Y, c.Z))
+	}
+}
+
+// fullNbrs returns the Full neighbours of a voxel, the one below it first.
+func fullNbrs(m *Matrix, x, y, z int) []Coordinate {
+	nbrs := [6]Coordinate{
+		{x, y - 1, z},
+		{x - 1, y, z}, {x + 1, y, z},
+		{x, y, z - 1}, {x, y, z + 1},
+		{x, y + 1, z},
+	}
+	var fn []Coordinate
+	for _, c := range nbrs {
+		if m.IsValidCoord(c) && m.IsFull(c.X, c.Y, c.Z) {
+			fn = append(fn, c)
+		}
+	}
+	return fn
+}
+
+func (g *groundTracker) rebuild(m *Matrix) {
+	g.res = m.Resolution()
+	n := g.res*g.res*g.res + 1
+	if len(g.parent) != n {
+		g.parent = make([]int32, n)
+		g.size = make([]int32, n)
+		g.ghost = make([]bool, n)
+	}
+	for i := range g.parent {
+		g.parent[i] = int32(i)
+		g.size[i] = 1
+		g.ghost[i] = false
+	}
+	g.numFull = 0
+	g.ghosts = 0
+	for x := 0; x < g.res; x++ {
+		for y := 0; y < g.res; y++ {
+			for z := 0; z < g.res; z++ {
+				if m.IsFull(x, y, z) {
+					g.numFull++
+					g.connect(m, x, y, z)
+				}
+			}
+		}
+	}
+	g.stale = false
+}
+
+// filled updates the tracker after the given voxel is changed from Void to
+// Full in the Matrix.
+func (g *groundTracker) filled(m *Matrix, c Coordinate) {
+	if g.stale || g.parent == nil {
+		return
+	}
+	g.numFull++
+	i := g.index(c.X, c.Y, c.Z)
+	if g.ghost[i] {
+		// A ghost is already in the set of the floor, which is only right
+		// if the voxel is grounded again.
+		g.ghost[i] = false
+		g.ghosts--
+		fr := g.find(g.floor())
+		grounded := c.Y == 0
+		for _, nc := range fullNbrs(m, c.X, c.Y, c.Z) {
+			if g.find(g.index(nc.X, nc.Y, nc.Z)) == fr {
+				grounded = true
+			}
+		}
+		if !grounded {
+			g.stale = true
+			return
+		}
+	}
+	g.connect(m, c.X, c.Y, c.Z)
+}
+
+// voided updates the tracker after the given voxel is changed from Full to
+// Void in the Matrix.
+func (g *groundTracker) voided(m *Matrix, c Coordinate) {
+	if g.stale || g.parent == nil {
+		return
+	}
+	wasGrounded := g.grounded()
+	g.numFull--
+	if !wasGrounded || !g.reachesFloor(m, c) {
+		g.stale = true
+		return
+	}
+	g.ghost[g.index(c.X, c.Y, c.Z)] = true
+	g.ghosts++
+}
+
+// reachesFloor checks whether every Full neighbour of the given voxel is
+// connected to the floor via other Full voxels. It searches downwards first,
+// and stops at voxels already known to be connected to the floor, so that it
+// usually only looks at a few voxels near the given one.
+func (g *groundTracker) reachesFloor(m *Matrix, c Coordinate) bool {
+	grounded := make(map[int32]bool)
+	for _, nc := range fullNbrs(m, c.X, c.Y, c.Z) {
+		seen := map[int32]bool{g.index(nc.X, nc.Y, nc.Z): true}
+		stack := []Coordinate{nc}
+		found := false
+		for len(stack) > 0 {
+			v := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if v.Y == 0 || grounded[g.index(v.X, v.Y, v.Z)] {
+				found = true
+				break
+			}
+			nbrs := fullNbrs(m, v.X, v.Y, v.Z)
+			for k := len(nbrs) - 1; k >= 0; k-- {
+				if i := g.index(nbrs[k].X, nbrs[k].Y, nbrs[k].Z); !seen[i] {
+					seen[i] = true
+					stack = append(stack, nbrs[k])
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+		// Everything seen is connected to this neighbour, and so to the
+		// floor.
+		for i := range seen {
+			grounded[i] = true
+		}
+	}
+	return true
+}
+
+// grounded checks whether every Full voxel is in the set of the floor.
+func (g *groundTracker) grounded() bool {
+	return int(g.size[g.find(g.floor())])-1-g.ghosts == g.numFull
+}
+
+// allGrounded checks whether every Full voxel in the Matrix is grounded.
+func (g *groundTracker) allGrounded(m *Matrix) bool {
+	if g.stale || g.parent == nil || g.res != m.Resolution() {
+		g.rebuild(m)
+	}
+	return g.grounded()
+}
+
+// floatingVoxel returns a Full voxel in the Matrix that is not grounded. It
+// should only be called after allGrounded has returned false.
+func (g *groundTracker) floatingVoxel(m *Matrix) (Coordinate, bool) {
+	fr := g.find(g.floor())
+	for x := 0; x < g.res; x++ {
+		for y := 0; y < g.res; y++ {
+			for z := 0; z < g.res; z++ {
+				if m.IsFull(x, y, z) && g.find(g.index(x, y, z)) != fr {
+					return Coordinate{x, y, z}, true
+				}
+			}
+		}
+	}
+	return Coordinate{}, false
+}
@@ -0,0 +1,301 @@
+package nmms
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+)
+
+// View selects how a Matrix is projected onto an image.
+type View int
+
+const (
+	// ObliqueView uses the same Projection as the viewer.Renderer.
+	ObliqueView View = iota
+	// FrontView looks along the Z-axis from z = 0.
+	FrontView
+	// TopView looks down along the Y-axis from the top of the Matrix.
+	TopView
+	// SideView looks along the X-axis from the right of the Matrix.
+	SideView
+)
+
+var viewNames = map[string]View{
+	"oblique": ObliqueView,
+	"front":   FrontView,
+	"top":     TopView,
+	"side":    SideView,
+}
+
+// ParseView returns the View with the given name.
+func ParseView(s string) (View, error) {
+	v, ok := viewNames[s]
+	if !ok {
+		return ObliqueView, fmt.Errorf("unknown view \"%s\"", s)
+	}
+	return v, nil
+}
+
+// RenderImage renders the Matrix and the Nanobots into an image of the given
+// size without needing a display.
+func RenderImage(m *Matrix, bots []Nanobot, v View, width,
+	height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{},
+		draw.Src)
+	if v == ObliqueView {
+		renderOblique(img, m, bots)
+	} else {
+		renderOrthographic(img, m, bots, v)
+	}
+	return img
+}
+
+// WriteImageFile writes the rendering of the Matrix and the Nanobots into the
+// given PNG file.
+func WriteImageFile(path string, m *Matrix, bots []Nanobot, v View, width,
+	height int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = png.Encode(f, RenderImage(m, bots, v, width, height)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func renderOblique(img *image.RGBA, m *Matrix, bots []Nanobot) {
+	b := img.Bounds()
+	p := NewProjection(m.Resolution(), b.Dx(), b.Dy())
+	bi, bj := p.BaseVertices()
+	fillPolygon(img, bi, bj, BaseColor)
+	for _, l := range p.BaseGridLines() {
+		drawLine(img, int(l[0]), int(l[1]), int(l[2]), int(l[3]),
+			BaseLineColor)
+	}
+
+	var fi, fj = make([]int16, 4), make([]int16, 4)
+	drawVoxel := func(vi, vj []int16, colors []color.RGBA) {
+		for f, vIdx := range VoxelFaces {
+			for k, v := range vIdx {
+				fi[k], fj[k] = vi[v], vj[v]
+			}
+			fillPolygon(img, fi, fj, colors[f])
+		}
+	}
+	p.VisitMatrix(m, func(vi, vj []int16) {
+		drawVoxel(vi, vj, FullCellColors)
+	})
+	var vi, vj = make([]int16, 7), make([]int16, 7)
+	for _, bot := range bots {
+		p.ProjectVoxel(bot.Pos.X, bot.Pos.Y, bot.Pos.Z, vi, vj, true)
+		drawVoxel(vi, vj, BotCellColors)
+	}
+}
+
+// viewCoord returns the Coordinate seen at the given depth from the given
+// position (u to the right, w upwards) on an orthographic view.
+func viewCoord(v View, res, u, w, d int) Coordinate {
+	switch v {
+	case TopView:
+		return Coordinate{u, res - 1 - d, w}
+	case SideView:
+		return Coordinate{res - 1 - d, w, res - 1 - u}
+	default:
+		return Coordinate{u, w, d}
+	}
+}
+
+// fromViewCoord is the inverse of viewCoord.
+func fromViewCoord(v View, res int, c Coordinate) (int, int, int) {
+	switch v {
+	case TopView:
+		return c.X, c.Z, res - 1 - c.Y
+	case SideView:
+		return res - 1 - c.Z, c.Y, res - 1 - c.X
+	default:
+		return c.X, c.Y, c.Z
+	}
+}
+
+func shade(c color.RGBA, d, res int) color.RGBA {
+	f := 1.0 - 0.6*float64(d)/float64(res)
+	return color.RGBA{uint8(float64(c.R) * f), uint8(float64(c.G) * f),
+		uint8(float64(c.B) * f), c.A}
+}
+
+func renderOrthographic(img *image.RGBA, m *Matrix, bots []Nanobot, v View) {
+	res := m.Resolution()
+	if res == 0 {
+		return
+	}
+	const gutterSize = 32
+	b := img.Bounds()
+	tile := IMax((iMin(b.Dx(), b.Dy())-2*gutterSize)/res, 1)
+	iOff := (b.Dx() - tile*res) / 2
+	jOff := (b.Dy() - tile*res) / 2
+	fillTile := func(u, w int, c color.RGBA) {
+		r := image.Rect(iOff+u*tile, b.Dy()-jOff-(w+1)*tile,
+			iOff+(u+1)*tile, b.Dy()-jOff-w*tile)
+		draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+
+	botDepth := make(map[Coordinate]int)
+	for _, bot := range bots {
+		u, w, d := fromViewCoord(v, res, bot.Pos)
+		botDepth[Coordinate{u, w, 0}] = d
+	}
+	for u := 0; u < res; u++ {
+		for w := 0; w < res; w++ {
+			c := BaseColor
+			d := 0
+			for ; d < res; d++ {
+				vc := viewCoord(v, res, u, w, d)
+				if m.IsFull(vc.X, vc.Y, vc.Z) {
+					c = shade(FullCellColors[frontFaceIdx], d, res)
+					break
+				}
+			}
+			if bd, ok := botDepth[Coordinate{u, w, 0}]; ok && bd < d {
+				c = shade(BotCellColors[frontFaceIdx], bd, res)
+			}
+			fillTile(u, w, c)
+		}
+	}
+}
+
+// fillPolygon fills a convex polygon with the given vertices.
+func fillPolygon(img *image.RGBA, vi, vj []int16, c color.RGBA) {
+	n := len(vi)
+	minJ, maxJ := int(vj[0]), int(vj[0])
+	for k := 1; k < n; k++ {
+		minJ = iMin(minJ, int(vj[k]))
+		maxJ = IMax(maxJ, int(vj[k]))
+	}
+	for j := minJ; j <= maxJ; j++ {
+		lo, hi := 0, -1
+		found := false
+		for k := 0; k < n; k++ {
+			i0, j0 := int(vi[k]), int(vj[k])
+			i1, j1 := int(vi[(k+1)%n]), int(vj[(k+1)%n])
+			if j < iMin(j0, j1) || j > IMax(j0, j1) {
+				continue
+			}
+			var is []int
+			if j0 == j1 {
+				is = []int{i0, i1}
+			} else {
+				is = []int{i0 + (j-j0)*(i1-i0)/(j1-j0)}
+			}
+			for _, i := range is {
+				if !found {
+					lo, hi, found = i, i, true
+				}
+				lo, hi = iMin(lo, i), IMax(hi, i)
+			}
+		}
+		for i := lo; i <= hi; i++ {
+			img.SetRGBA(i, j, c)
+		}
+	}
+}
+
+// drawLine draws a line between the given end-points using Bresenham's
+// algorithm.
+func drawLine(img *image.RGBA, i0, j0, i1, j1 int, c color.RGBA) {
+	di, dj := iAbs(i1-i0), -iAbs(j1-j0)
+	si, sj := 1, 1
+	if i0 > i1 {
+		si = -1
+	}
+	if j0 > j1 {
+		sj = -1
+	}
+	e := di + dj
+	for {
+		img.SetRGBA(i0, j0, c)
+		if i0 == i1 && j0 == j1 {
+			return
+		}
+		if 2*e >= dj {
+			e += dj
+			i0 += si
+		}
+		if 2*e <= di {
+			e += di
+			j0 += sj
+		}
+	}
+}
+
+const (
+	maxGifFrames = 256
+	gifDelay     = 10 // In 100ths of a second.
+)
+
+// GifRecorder records the execution of a Trace as an animated GIF. It halves
+// the number of frames, and doubles the number of time-steps between frames,
+// whenever there are too many of them.
+type GifRecorder struct {
+	view          View
+	width, height int
+	interval      int
+	lastStep      int
+	frames        []*image.Paletted
+}
+
+func NewGifRecorder(v View, width, height int) *GifRecorder {
+	return &GifRecorder{view: v, width: width, height: height, interval: 1,
+		lastStep: -1}
+}
+
+// Record adds a frame for the current state of the system, if it is due.
+func (g *GifRecorder) Record(n *NmmSystem) {
+	if n.Step%g.interval != 0 || n.Step == g.lastStep {
+		return
+	}
+	g.addFrame(n)
+	if len(g.frames) > maxGifFrames {
+		for i := 0; 2*i < len(g.frames); i++ {
+			g.frames[i] = g.frames[2*i]
+		}
+		g.frames = g.frames[:(len(g.frames)+1)/2]
+		g.interval *= 2
+	}
+}
+
+func (g *GifRecorder) addFrame(n *NmmSystem) {
+	img := RenderImage(&n.Mat, n.Bots, g.view, g.width, g.height)
+	pImg := image.NewPaletted(img.Bounds(), palette.Plan9)
+	draw.Draw(pImg, img.Bounds(), img, image.Point{}, draw.Src)
+	g.frames = append(g.frames, pImg)
+	g.lastStep = n.Step
+}
+
+// WriteToFile writes the animated GIF into the given file, after adding a
+// frame for the final state of the system.
+func (g *GifRecorder) WriteToFile(path string, n *NmmSystem) error {
+	if n.Step != g.lastStep {
+		g.addFrame(n)
+	}
+	anim := gif.GIF{Image: g.frames, Delay: make([]int, len(g.frames))}
+	for i := range anim.Delay {
+		anim.Delay[i] = gifDelay
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = gif.EncodeAll(f, &anim); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math"
+	"strings"
 )
 
 const (
 	numBitsPerByte = 8
+	maxResolution  = 250
 )
 
 type Coordinate struct {
@@ -34,9 +36,9 @@ func (m *Matrix) updateBoundingBox(x, y, z int) {
 	m.bbMin.Y = iMin(m.bbMin.Y, y)
 	m.bbMin.Z = iMin(m.bbMin.Z, z)
 
-	m.bbMax.X = iMax(m.bbMax.X, x)
-	m.bbMax.Y = iMax(m.bbMax.Y, y)
-	m.bbMax.Z = iMax(m.bbMax.Z, z)
+	m.bbMax.X = IMax(m.bbMax.X, x)
+	m.bbMax.Y = IMax(m.bbMax.Y, y)
+	m.bbMax.Z = IMax(m.bbMax.Z, z)
 }
 
 func (m *Matrix) computeBoundingBox() {
@@ -61,7 +63,97 @@ func (m *Matrix) computeBoundingBox() {
 			bitIdx++
 		}
 	}
-	fmt.Printf("Bounding Box: %v %v\n", &m.bbMin, &m.bbMax)
+}
+
+func numDataBytes(res int) int {
+	return int(math.Ceil(float64(res*res*res)/float64(numBitsPerByte))) + 1
+}
+
+// NewMatrix returns an empty Matrix with the given resolution.
+func NewMatrix(res int) (*Matrix, error) {
+	if res < 1 || res > maxResolution {
+		return nil, fmt.Errorf("bad resolution %d", res)
+	}
+	m := &Matrix{res: res, data: make([]byte, numDataBytes(res))}
+	m.data[0] = byte(res)
+	m.bbMin = Coordinate{res, res, res}
+	return m, nil
+}
+
+// NewMatrixFromCoords returns a Matrix with the given resolution where only
+// the Cells at the given Coordinates are Full.
+func NewMatrixFromCoords(res int, coords []Coordinate) (*Matrix, error) {
+	m, err := NewMatrix(res)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range coords {
+		if !m.IsValidCoord(c) {
+			return nil, fmt.Errorf("Coordinate %v is out of bounds", &c)
+		}
+		m.SetFull(c.X, c.Y, c.Z)
+	}
+	return m, nil
+}
+
+// NewMatrixFromLayout returns a Matrix from an ASCII layout of its slices.
+// Each slice is a horizontal plane, starting from y = 0, and the slices are
+// separated by blank lines. Each line in a slice is a row of Cells along the
+// X-axis, starting from z = 0, with '#' for a Full Cell and '.' for a Void
+// Cell. The resolution of the Matrix is the length of the lines.
+func NewMatrixFromLayout(layout string) (*Matrix, error) {
+	var slices [][]string
+	var curr []string
+	for _, l := range strings.Split(layout, "\n") {
+		l = strings.TrimSpace(l)
+		if len(l) == 0 {
+			if len(curr) > 0 {
+				slices = append(slices, curr)
+				curr = nil
+			}
+			continue
+		}
+		curr = append(curr, l)
+	}
+	if len(curr) > 0 {
+		slices = append(slices, curr)
+	}
+	if len(slices) == 0 {
+		return nil, fmt.Errorf("empty layout")
+	}
+
+	res := len(slices[0][0])
+	if len(slices) > res {
+		return nil, fmt.Errorf("%d slices for resolution %d", len(slices), res)
+	}
+	m, err := NewMatrix(res)
+	if err != nil {
+		return nil, err
+	}
+	for y, sl := range slices {
+		if len(sl) != res {
+			return nil, fmt.Errorf("slice %d has %d rows instead of %d", y,
+				len(sl), res)
+		}
+		for z, row := range sl {
+			if len(row) != res {
+				return nil, fmt.Errorf(
+					"row %d of slice %d has %d Cells instead of %d", z, y,
+					len(row), res)
+			}
+			for x, r := range row {
+				switch r {
+				case '#':
+					m.SetFull(x, y, z)
+				case '.':
+				default:
+					return nil, fmt.Errorf(
+						"bad Cell '%c' in row %d of slice %d", r, z, y)
+				}
+			}
+		}
+	}
+	return m, nil
 }
 
 // ReadFromFile populates the Matrix using the given Model file.
@@ -72,8 +164,7 @@ func (m *Matrix) ReadFromFile(path string) error {
 		return err
 	}
 	m.res = int(m.data[0])
-	exp :=
-		int(math.Ceil(float64(m.res*m.res*m.res)/float64(numBitsPerByte))) + 1
+	exp := numDataBytes(m.res)
 	if len(m.data) < exp {
 		return fmt.Errorf(
 			"bad data in \"%s\" - %d expected vs %d actual bytes", path, exp,
@@ -83,6 +174,47 @@ func (m *Matrix) ReadFromFile(path string) error {
 	return nil
 }
 
+// WriteToFile writes the Matrix into the given Model file.
+func (m *Matrix) WriteToFile(path string) error {
+	return ioutil.WriteFile(path, m.data[:numDataBytes(m.res)], 0644)
+}
+
+// Copy returns a copy of the Matrix.
+func (m *Matrix) Copy() *Matrix {
+	c := *m
+	c.data = append([]byte{}, m.data...)
+	return &c
+}
+
+// Crop returns a copy of the Matrix with the smallest resolution that can
+// hold its Full Cells, keeping the margins needed by the Nanobots around them.
+func (m *Matrix) Crop() (*Matrix, error) {
+	// The bounding box is computed on a copy, which shares the voxels, so
+	// that the Matrix is left as it is.
+	b := *m
+	b.computeBoundingBox()
+	bbMin, bbMax := b.bbMin, b.bbMax
+	if bbMin.X > bbMax.X {
+		return NewMatrix(1)
+	}
+	size := bbMax.Add(&Coordinate{1 - bbMin.X, 1 - bbMin.Y, 1 - bbMin.Z})
+	res := IMax(size.X+2, IMax(size.Y+1, size.Z+2))
+	c, err := NewMatrix(res)
+	if err != nil {
+		return nil, err
+	}
+	for x := bbMin.X; x <= bbMax.X; x++ {
+		for y := bbMin.Y; y <= bbMax.Y; y++ {
+			for z := bbMin.Z; z <= bbMax.Z; z++ {
+				if m.IsFull(x, y, z) {
+					c.SetFull(x-bbMin.X+1, y-bbMin.Y, z-bbMin.Z+1)
+				}
+			}
+		}
+	}
+	return c, nil
+}
+
 // Resolution returns the current resolution of the Matrix.
 func (m *Matrix) Resolution() int {
 	return m.res
@@ -103,6 +235,12 @@ func (m *Matrix) Clear() {
 	m.bbMax = Coordinate{0, 0, 0}
 }
 
+// IsValidCoord checks whether a given Coordinate lies within the Matrix.
+func (m *Matrix) IsValidCoord(c Coordinate) bool {
+	return c.X >= 0 && c.X < m.res && c.Y >= 0 && c.Y < m.res && c.Z >= 0 &&
+		c.Z < m.res
+}
+
 func (m *Matrix) translateCoord(x, y, z int) (int, uint) {
 	if x < 0 || x >= m.res || y < 0 || y >= m.res || z < 0 || z >= m.res {
 		panic(fmt.Sprintf(
@@ -132,3 +270,22 @@ func (m *Matrix) SetVoid(x, y, z int) {
 	m.data[byteIdx] &= (1 << bitIdx) ^ 0xFF
 	// TODO: We should update the bounding-box here.
 }
+
+// Differences returns the Coordinates of the Cells that are Full in one of the
+// Matrices, but not in the other.
+func (m *Matrix) Differences(o *Matrix) ([]Coordinate, error) {
+	if m.res != o.res {
+		return nil, fmt.Errorf("resolution %d differs from %d", m.res, o.res)
+	}
+	var diffs []Coordinate
+	for x := 0; x < m.res; x++ {
+		for y := 0; y < m.res; y++ {
+			for z := 0; z < m.res; z++ {
+				if m.IsFull(x, y, z) != o.IsFull(x, y, z) {
+					diffs = append(diffs, Coordinate{x, y, z})
+				}
+			}
+		}
+	}
+	return diffs, nil
+}
@@ -1,7 +1,22 @@
 package nmms
 
+const (
+	maxNanobots = 40
+)
+
 type Nanobot struct {
 	Bid   int
 	Pos   Coordinate
 	Seeds []int
 }
+
+// InitialBots returns the Nanobots at the start of the execution of a Trace,
+// which is a single Nanobot at the origin holding the seeds for the others.
+func InitialBots() []Nanobot {
+	b := Nanobot{Bid: 1, Pos: Coordinate{}}
+	b.Seeds = make([]int, maxNanobots-1)
+	for i := range b.Seeds {
+		b.Seeds[i] = i + 2
+	}
+	return []Nanobot{b}
+}
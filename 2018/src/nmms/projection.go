@@ -0,0 +1,154 @@
+package nmms
+
+import (
+	"image/color"
+	"math"
+)
+
+const (
+	frontFaceIdx int = iota
+	upFaceIdx
+	rightFaceIdx
+)
+
+// VoxelFaces holds the indices of the projected vertices of a voxel forming
+// each visible face.
+var VoxelFaces = [...][4]int{
+	frontFaceIdx: {0, 1, 6, 5},
+	upFaceIdx:    {1, 2, 3, 6},
+	rightFaceIdx: {5, 6, 3, 4},
+}
+
+// The colours of the base of the Matrix, and of each visible face of a Full
+// voxel and of a Nanobot.
+var (
+	BaseColor      = color.RGBA{64, 64, 64, 255}
+	BaseLineColor  = color.RGBA{0, 0, 0, 255}
+	FullCellColors = []color.RGBA{
+		frontFaceIdx: {255, 255, 255, 255},
+		upFaceIdx:    {225, 225, 225, 255},
+		rightFaceIdx: {200, 200, 200, 255},
+	}
+	BotCellColors = []color.RGBA{
+		frontFaceIdx: {255, 255, 0, 255},
+		upFaceIdx:    {225, 225, 0, 255},
+		rightFaceIdx: {200, 200, 0, 255},
+	}
+)
+
+// Projection maps the voxels of a Matrix onto an area of the given size using
+// an oblique projection, with the Z-axis going into the area at 45 degrees.
+type Projection struct {
+	width, height        int
+	res                  int
+	tileWidth, tileDelta int
+	iOff, jOff           int
+}
+
+// NewProjection returns a Projection of a Matrix with the given resolution
+// onto an area of the given size.
+func NewProjection(res, width, height int) Projection {
+	p := Projection{width: width, height: height, res: res}
+
+	const sinCos45 = 0.70710678118   // sin/cos of 45 deg, the projection-angle.
+	const projScale = sinCos45 / 2.0 // Projected length of a unit length.
+	const gutterSize = 32
+	maxMatSize := iMin(width, height) - 2*gutterSize
+	p.tileWidth = int(math.Floor(float64(maxMatSize) /
+		(1.0 + projScale) / float64(p.res)))
+	p.tileDelta = int(math.Floor(
+		float64(maxMatSize-p.tileWidth*p.res) / float64(p.res)))
+
+	matRenderSize := p.res * (p.tileWidth + p.tileDelta)
+	p.iOff = (width - matRenderSize) / 2
+	p.jOff = (height - matRenderSize) / 2
+	return p
+}
+
+// BaseVertices returns the projected vertices of the base of the Matrix.
+func (p *Projection) BaseVertices() ([]int16, []int16) {
+	var bi, bj = make([]int16, 4), make([]int16, 4)
+	normShift := int16(p.res * p.tileWidth)
+	projShift := int16(p.res * p.tileDelta)
+	bi[0] = int16(p.iOff)
+	bj[0] = int16(p.height - p.jOff)
+	bi[1] = bi[0] + projShift
+	bj[1] = bj[0] - projShift
+	bi[2] = bi[1] + normShift
+	bj[2] = bj[1]
+	bi[3] = bi[2] - projShift
+	bj[3] = bj[2] + projShift
+	return bi, bj
+}
+
+// BaseGridLines returns the end-points (i0, j0, i1, j1) of the projected grid
+// lines on the base of the Matrix, unless the grid is too fine to be useful.
+func (p *Projection) BaseGridLines() [][4]int32 {
+	const minGridWidth = 5
+	if p.tileWidth < minGridWidth {
+		return nil
+	}
+	var lines [][4]int32
+	normShift := int32(p.res * p.tileWidth)
+	projShift := int32(p.res * p.tileDelta)
+	for x := 1; x < p.res; x++ {
+		i0 := int32(p.iOff + x*p.tileWidth)
+		j0 := int32(p.height - p.jOff)
+		lines = append(lines, [4]int32{i0, j0, i0 + projShift, j0 - projShift})
+	}
+	for z := 1; z < p.res; z++ {
+		i0 := int32(p.iOff + z*p.tileDelta)
+		j0 := int32(p.height - p.jOff - z*p.tileDelta)
+		lines = append(lines, [4]int32{i0, j0, i0 + normShift, j0})
+	}
+	return lines
+}
+
+// ProjectVoxel computes the projected vertices of the voxel at the given
+// Coordinate. The vertices that lie only on the back face (2, 3 and 4) are
+// computed only if back is true.
+func (p *Projection) ProjectVoxel(x, y, z int, vi, vj []int16, back bool) {
+	vi[0] = int16(p.iOff + x*p.tileWidth + z*p.tileDelta)
+	vj[0] = int16(p.height - (p.jOff + y*p.tileWidth + z*p.tileDelta))
+	vi[1] = vi[0]
+	vj[1] = vj[0] - int16(p.tileWidth)
+	if back {
+		vi[2] = vi[1] + int16(p.tileDelta)
+		vj[2] = vj[1] - int16(p.tileDelta)
+		vi[3] = vi[2] + int16(p.tileWidth)
+		vj[3] = vj[2]
+		vi[4] = vi[3]
+		vj[4] = vj[3] + int16(p.tileWidth)
+	}
+	vi[5] = vi[0] + int16(p.tileWidth)
+	vj[5] = vj[0]
+	vi[6] = vi[5]
+	vj[6] = vj[5] - int16(p.tileWidth)
+}
+
+// VisitMatrix calls the given function with the projected vertices of each
+// visible run of Full voxels along the Z-axis, from back to front.
+func (p *Projection) VisitMatrix(m *Matrix, f func(vi, vj []int16)) {
+	var vi, vj = make([]int16, 7), make([]int16, 7)
+	// Without bounding-box: x:0->res-1, y:0->res-1, z:res-1->0
+	bbMin, bbMax := m.BoundingBox()
+	for x := bbMin.X; x <= bbMax.X; x++ {
+		for y := bbMin.Y; y <= bbMax.Y; y++ {
+			prevFull := false
+			for z := bbMax.Z; z >= bbMin.Z; z-- {
+				if !m.IsFull(x, y, z) {
+					if prevFull {
+						f(vi, vj)
+					}
+					prevFull = false
+					continue
+				}
+				p.ProjectVoxel(x, y, z, vi, vj, !prevFull)
+				prevFull = true
+			}
+			if prevFull {
+				f(vi, vj)
+			}
+		}
+	}
+}
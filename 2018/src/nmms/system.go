@@ -2,6 +2,13 @@ package nmms
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	harmonicsEnergy = "(Harmonics)"
+	nanobotsEnergy  = "(Nanobots)"
 )
 
 type NmmSystem struct {
@@ -10,16 +17,24 @@ type NmmSystem struct {
 	Mat           Matrix
 	Bots          []Nanobot
 	Trc           Tracer
+	Step          int
+	EnergyUse     map[string]int
+
+	grounds groundTracker
+
+	// State used only while executing a time-step.
+	cmds     []Command
+	newBots  []Nanobot
+	doneBots map[int]bool
 }
 
+// ExecuteStep executes a single time-step of the system, taking one Command
+// from the Trace for each active Nanobot in the order of their IDs.
 func (n *NmmSystem) ExecuteStep() error {
 	numBots := len(n.Bots)
 	if numBots == 0 {
 		return nil
 	}
-	if numBots > 1 {
-		return fmt.Errorf("cannot handle more than one Nanobot right now")
-	}
 	cmds, err := n.Trc.TakeCommands(numBots)
 	if err != nil {
 		return err
@@ -27,20 +42,247 @@ func (n *NmmSystem) ExecuteStep() error {
 	if len(cmds) == 0 {
 		return nil
 	}
+	n.sortBots()
 
-	energyCost := 0
+	if n.EnergyUse == nil {
+		n.EnergyUse = make(map[string]int)
+	}
 	resCubed := n.Mat.Resolution() * n.Mat.Resolution() * n.Mat.Resolution()
+	harmonicsCost := 3 * resCubed
 	if n.HighHarmonics {
-		energyCost += 30 * resCubed
-	} else {
-		energyCost += 3 * resCubed
+		harmonicsCost = 30 * resCubed
 	}
-	energyCost += 20 * len(n.Bots)
+	botsCost := 20 * numBots
 
-	if err = cmds[0].Execute(n, 0); err != nil {
+	if err = n.checkInterference(cmds); err != nil {
+		return err
+	}
+	n.cmds = cmds
+	n.newBots = nil
+	n.doneBots = make(map[int]bool)
+	for i, c := range cmds {
+		e0 := n.Energy
+		if err = c.Execute(n, i); err != nil {
+			return fmt.Errorf("step %d, Nanobot %d: %v", n.Step,
+				n.Bots[i].Bid, err)
+		}
+		n.EnergyUse[CmdName(c)] += n.Energy - e0
+	}
+	n.updateBots()
+	n.Energy += harmonicsCost + botsCost
+	n.EnergyUse[harmonicsEnergy] += harmonicsCost
+	n.EnergyUse[nanobotsEnergy] += botsCost
+	if err = n.checkGrounded(); err != nil {
 		return err
 	}
-	n.Energy += energyCost
+	n.Step++
+
+	return nil
+}
+
+// fill sets the given voxel in the Matrix to be Full.
+func (n *NmmSystem) fill(c Coordinate) {
+	n.Mat.SetFull(c.X, c.Y, c.Z)
+	n.grounds.filled(&n.Mat, c)
+}
+
+// void sets the given voxel in the Matrix to be Void.
+func (n *NmmSystem) void(c Coordinate) {
+	n.Mat.SetVoid(c.X, c.Y, c.Z)
+	n.grounds.voided(&n.Mat, c)
+}
+
+// IsGrounded checks whether all the Full voxels in the Matrix are grounded.
+func (n *NmmSystem) IsGrounded() bool {
+	return n.grounds.allGrounded(&n.Mat)
+}
+
+// checkGrounded verifies that all Full voxels in the Matrix are grounded, if
+// the harmonics are Low.
+func (n *NmmSystem) checkGrounded() error {
+	if n.HighHarmonics || n.grounds.allGrounded(&n.Mat) {
+		return nil
+	}
+	if c, ok := n.grounds.floatingVoxel(&n.Mat); ok {
+		return fmt.Errorf(
+			"step %d: Full voxel %v is not grounded under Low harmonics",
+			n.Step, &c)
+	}
+	return fmt.Errorf("step %d: Full voxels are not grounded under Low "+
+		"harmonics", n.Step)
+}
+
+// CmdName returns the name of the Command, e.g. "SMove".
+func CmdName(c Command) string {
+	return strings.Fields(c.String())[0]
+}
+
+// PrintEnergyUse prints the breakdown of the Energy used so far by the
+// harmonics, the active Nanobots and each type of Command.
+func (n *NmmSystem) PrintEnergyUse() {
+	keys := make([]string, 0, len(n.EnergyUse))
+	for k := range n.EnergyUse {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e := n.EnergyUse[k]
+		pct := 0.0
+		if n.Energy != 0 {
+			pct = 100.0 * float64(e) / float64(n.Energy)
+		}
+		fmt.Printf("  %-12s %14d (%5.1f%%)\n", k, e, pct)
+	}
+}
 
+// Copy returns a copy of the system that can be executed independently of it.
+func (n *NmmSystem) Copy() *NmmSystem {
+	c := &NmmSystem{
+		Energy:        n.Energy,
+		HighHarmonics: n.HighHarmonics,
+		Mat:           *n.Mat.Copy(),
+		Trc:           n.Trc,
+		Step:          n.Step,
+	}
+	c.Bots = make([]Nanobot, len(n.Bots))
+	for i, b := range n.Bots {
+		b.Seeds = append([]int{}, b.Seeds...)
+		c.Bots[i] = b
+	}
+	if n.EnergyUse != nil {
+		c.EnergyUse = make(map[string]int)
+		for k, v := range n.EnergyUse {
+			c.EnergyUse[k] = v
+		}
+	}
+	return c
+}
+
+// Run executes time-steps until there are no more active Nanobots, returning
+// an error if the Trace ends before that.
+func (n *NmmSystem) Run() error {
+	for len(n.Bots) > 0 {
+		if n.Trc.IsEmpty() {
+			return fmt.Errorf("Trace ended at step %d with %d active Nanobots",
+				n.Step, len(n.Bots))
+		}
+		if err := n.ExecuteStep(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *NmmSystem) sortBots() {
+	sort.Slice(n.Bots, func(i, j int) bool {
+		return n.Bots[i].Bid < n.Bots[j].Bid
+	})
+}
+
+// botAt returns the index of the Nanobot at the given Coordinate or -1 if
+// there is no such Nanobot.
+func (n *NmmSystem) botAt(c Coordinate) int {
+	for i := range n.Bots {
+		if n.Bots[i].Pos == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// addBot schedules the addition of a Nanobot at the end of the time-step.
+func (n *NmmSystem) addBot(b Nanobot) {
+	n.newBots = append(n.newBots, b)
+}
+
+// removeBot schedules the removal of a Nanobot at the end of the time-step.
+func (n *NmmSystem) removeBot(bIdx int) {
+	n.doneBots[bIdx] = true
+}
+
+func (n *NmmSystem) updateBots() {
+	if len(n.newBots) == 0 && len(n.doneBots) == 0 {
+		return
+	}
+	bots := make([]Nanobot, 0, len(n.Bots)+len(n.newBots))
+	for i, b := range n.Bots {
+		if !n.doneBots[i] {
+			bots = append(bots, b)
+		}
+	}
+	n.Bots = append(bots, n.newBots...)
+	n.sortBots()
+	n.newBots = nil
+}
+
+// groupLeader returns the index of the first Nanobot in the group of Nanobots
+// executing the same kind of group-Command on the same region as the given
+// Nanobot. For other Commands, it returns the index of the given Nanobot.
+func (n *NmmSystem) groupLeader(cmds []Command, bIdx int) int {
+	g, ok := cmds[bIdx].(groupCmd)
+	if !ok {
+		return bIdx
+	}
+	_, _, fill := g.operands()
+	rMin, rMax := groupRegion(n, bIdx, g)
+	for i := 0; i < bIdx; i++ {
+		gi, ok := cmds[i].(groupCmd)
+		if !ok {
+			continue
+		}
+		_, _, fi := gi.operands()
+		if fi != fill {
+			continue
+		}
+		if gMin, gMax := groupRegion(n, i, gi); gMin == rMin && gMax == rMax {
+			return i
+		}
+	}
+	return bIdx
+}
+
+// CheckMoves verifies, without executing them, that the given Commands for the
+// active Nanobots in the order of their IDs do not interfere with each other
+// and that the moves among them stay within the Matrix and avoid its Full
+// voxels. The system is left as it is.
+func (n *NmmSystem) CheckMoves(cmds []Command) error {
+	if len(cmds) != len(n.Bots) {
+		return fmt.Errorf("got %d Commands for %d Nanobots", len(cmds),
+			len(n.Bots))
+	}
+	// The Nanobots are sorted on a copy, which shares the Matrix.
+	s := *n
+	s.Bots = append([]Nanobot(nil), n.Bots...)
+	s.sortBots()
+	if err := s.checkInterference(cmds); err != nil {
+		return err
+	}
+	for i, c := range cmds {
+		switch c.(type) {
+		case *SMoveCmd, *LMoveCmd:
+			if err := checkMoveRegion(&s, c.volatileCoords(&s, i)); err != nil {
+				return fmt.Errorf("step %d, Nanobot %d: %v %v", s.Step,
+					s.Bots[i].Bid, c, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkInterference verifies that the volatile Coordinates of the Commands in
+// a time-step do not overlap, except within a group of Nanobots.
+func (n *NmmSystem) checkInterference(cmds []Command) error {
+	owners := make(map[Coordinate]int)
+	for i, c := range cmds {
+		g := n.groupLeader(cmds, i)
+		for _, v := range c.volatileCoords(n, i) {
+			if j, ok := owners[v]; ok && j != g {
+				return fmt.Errorf(
+					"step %d: Nanobots %d (%v) and %d (%v) interfere at %v",
+					n.Step, n.Bots[j].Bid, cmds[j], n.Bots[i].Bid, c, &v)
+			}
+			owners[v] = g
+		}
+	}
 	return nil
 }
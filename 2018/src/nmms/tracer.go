@@ -1,36 +1,169 @@
 package nmms
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
 )
 
+const (
+	// The size of the longest Command-encoding.
+	maxCmdSize = 4
+)
+
+// traceStream is the source of the encoded Commands of a Tracer. It is shared
+// by the copies of the Tracer.
+type traceStream struct {
+	src    io.Reader
+	r      *bufio.Reader
+	offset int64 // The offset of the next byte to be read from r.
+	closer io.Closer
+}
+
+type decodedCmd struct {
+	cmd  Command
+	size int
+}
+
+// Tracer decodes the Commands of a Trace as they are needed, so that a Trace
+// can be replayed in constant memory. Copies of a Tracer can be read
+// independently of each other if the source of the Trace supports seeking.
 type Tracer struct {
-	data []byte
+	s      *traceStream
+	offset int64 // The offset of the next Command to be taken.
+	// The Commands that have been decoded but not yet taken.
+	ahead     []decodedCmd
+	aheadSize int64
 }
 
-// ReadFromFile populates the Tracer using the given Trace file.
+// ReadFromFile populates the Tracer using the given Trace file, which is
+// read as it is needed. A path of "-" reads the Trace from the standard input.
 func (t *Tracer) ReadFromFile(path string) error {
-	var err error
-	t.data, err = ioutil.ReadFile(path)
+	if path == "-" {
+		t.SetReader(os.Stdin)
+		return nil
+	}
+	f, err := os.Open(path)
 	if err != nil {
 		return err
 	}
+	t.SetReader(f)
+	t.s.closer = f
 	return nil
 }
 
-func (t *Tracer) TakeCommands(n int) ([]Command, error) {
+// SetReader populates the Tracer using the Trace read from r as it is needed.
+func (t *Tracer) SetReader(r io.Reader) {
+	*t = Tracer{s: &traceStream{src: r, r: bufio.NewReader(r)}}
+}
+
+// SetCommands populates the Tracer using the given Commands.
+func (t *Tracer) SetCommands(cmds []Command) {
+	t.SetReader(bytes.NewReader(EncodeCommands(cmds)))
+}
+
+// Close closes the Trace file read by the Tracer, if any.
+func (t *Tracer) Close() error {
+	if t.s == nil || t.s.closer == nil {
+		return nil
+	}
+	err := t.s.closer.Close()
+	t.s.closer = nil
+	return err
+}
+
+// IsEmpty checks whether all the Commands in the Tracer have been taken. A
+// Tracer that fails to read the Trace is not empty, so that the error is
+// reported when taking Commands.
+func (t *Tracer) IsEmpty() bool {
+	if err := t.fill(1); err != nil {
+		return false
+	}
+	return len(t.ahead) == 0
+}
+
+// PeekCommands returns the next n Commands in the Tracer without taking them.
+func (t *Tracer) PeekCommands(n int) ([]Command, error) {
+	if err := t.fill(n); err != nil {
+		return nil, err
+	}
 	var cmds []Command
-	if len(t.data) == 0 {
+	if len(t.ahead) == 0 {
 		return cmds, nil
 	}
+	if len(t.ahead) < n {
+		return nil, fmt.Errorf("byte offset %d: premature end of "+
+			"Command-stream", t.offset+t.aheadSize)
+	}
 	cmds = make([]Command, n)
-	for i := 0; i < n; i++ {
-		c, offset, err := DecodeNextCommand(t.data)
+	for i := range cmds {
+		cmds[i] = t.ahead[i].cmd
+	}
+	return cmds, nil
+}
+
+func (t *Tracer) TakeCommands(n int) ([]Command, error) {
+	cmds, err := t.PeekCommands(n)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range t.ahead[:len(cmds)] {
+		t.offset += int64(d.size)
+		t.aheadSize -= int64(d.size)
+	}
+	t.ahead = t.ahead[len(cmds):]
+	return cmds, nil
+}
+
+// fill decodes Commands from the Trace until at least n of them have not yet
+// been taken, or the Trace ends.
+func (t *Tracer) fill(n int) error {
+	if len(t.ahead) >= n || t.s == nil {
+		return nil
+	}
+	s := t.s
+	pos := t.offset + t.aheadSize
+	if s.offset != pos {
+		// Another copy of the Tracer has moved the stream.
+		seeker, ok := s.src.(io.Seeker)
+		if !ok {
+			return fmt.Errorf("byte offset %d: cannot seek in the Trace", pos)
+		}
+		if _, err := seeker.Seek(pos, io.SeekStart); err != nil {
+			return fmt.Errorf("byte offset %d: %v", pos, err)
+		}
+		s.r.Reset(s.src)
+		s.offset = pos
+	}
+	// Never append into an array shared with a copy of the Tracer.
+	ahead := t.ahead[:len(t.ahead):len(t.ahead)]
+	for len(ahead) < n {
+		buf, err := s.r.Peek(maxCmdSize)
+		if err != nil && err != io.EOF {
+			t.ahead = ahead
+			return fmt.Errorf("byte offset %d: %v", s.offset, err)
+		}
+		if len(buf) == 0 {
+			break
+		}
+		c, size, err := DecodeNextCommand(buf)
 		if err != nil {
-			return nil, err
+			t.ahead = ahead
+			return fmt.Errorf("byte offset %d: %v", s.offset, err)
 		}
-		cmds[i] = c
-		t.data = t.data[offset:]
+		s.r.Discard(size)
+		s.offset += int64(size)
+		ahead = append(ahead, decodedCmd{c, size})
+		t.aheadSize += int64(size)
 	}
-	return cmds, nil
+	t.ahead = ahead
+	return nil
+}
+
+// WriteTraceFile writes the given Commands into a Trace file.
+func WriteTraceFile(path string, cmds []Command) error {
+	return ioutil.WriteFile(path, EncodeCommands(cmds), 0644)
 }
@@ -14,6 +14,16 @@ func iAbs(a int) int {
 	return a
 }
 
+func iSign(a int) int {
+	switch {
+	case a < 0:
+		return -1
+	case a > 0:
+		return 1
+	}
+	return 0
+}
+
 func iMin(a, b int) int {
 	if a <= b {
 		return a
@@ -21,7 +31,8 @@ func iMin(a, b int) int {
 	return b
 }
 
-func iMax(a, b int) int {
+// IMax returns the larger of two integers.
+func IMax(a, b int) int {
 	if a >= b {
 		return a
 	}
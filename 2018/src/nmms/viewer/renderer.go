@@ -0,0 +1,151 @@
+// Package viewer displays an NmmSystem in a window using SDL. It is kept out
+// of package nmms so that the tools that do not need a display can be built
+// without SDL.
+// TODO: Use OpenGL to make this much more efficient and flexible.
+package viewer
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"nmms"
+)
+
+const (
+	winWidth  = 1024
+	winHeight = 768
+)
+
+type drawParams struct {
+	proj           nmms.Projection
+	baseColor      sdl.Color
+	baseLineColor  sdl.Color
+	fullCellColors []sdl.Color
+	botCellColors  []sdl.Color
+}
+
+type Renderer struct {
+	window   *sdl.Window
+	renderer *sdl.Renderer
+	params   drawParams
+}
+
+func checkGfx(succ bool) {
+	if !succ {
+		fmt.Printf("GFX_ERROR: Call failed.\n")
+	}
+}
+
+func (r *Renderer) Init(n *nmms.NmmSystem) error {
+	var err error
+	if err = sdl.Init(sdl.INIT_VIDEO | sdl.INIT_EVENTS); err != nil {
+		return err
+	}
+	r.window, err = sdl.CreateWindow("Matrix Viewer (ICFPC 2018)",
+		sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED, winWidth, winHeight,
+		sdl.WINDOW_SHOWN)
+	if err != nil {
+		return err
+	}
+	r.renderer, err = sdl.CreateRenderer(r.window, -1, sdl.RENDERER_ACCELERATED)
+
+	r.initDrawParams(n)
+
+	r.renderer.SetDrawColor(0, 0, 0, 255)
+	r.renderer.Clear()
+	r.renderBase()
+	r.renderer.Present()
+
+	return nil
+}
+
+func toSdlColor(c color.RGBA) sdl.Color {
+	return sdl.Color{R: c.R, G: c.G, B: c.B, A: c.A}
+}
+
+func toSdlColors(cs []color.RGBA) []sdl.Color {
+	scs := make([]sdl.Color, len(cs))
+	for i, c := range cs {
+		scs[i] = toSdlColor(c)
+	}
+	return scs
+}
+
+func (r *Renderer) initDrawParams(n *nmms.NmmSystem) {
+	r.params.proj = nmms.NewProjection(n.Mat.Resolution(), winWidth,
+		winHeight)
+	r.params.baseColor = toSdlColor(nmms.BaseColor)
+	r.params.baseLineColor = toSdlColor(nmms.BaseLineColor)
+	r.params.fullCellColors = toSdlColors(nmms.FullCellColors)
+	r.params.botCellColors = toSdlColors(nmms.BotCellColors)
+}
+
+func (r *Renderer) Quit() {
+	if r.window != nil {
+		r.window.Destroy()
+	}
+	sdl.Quit()
+}
+
+func (r *Renderer) shouldContinue() bool {
+	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+		switch t := event.(type) {
+		case *sdl.QuitEvent:
+			return false
+		case *sdl.KeyboardEvent:
+			if t.Type == sdl.KEYDOWN && t.Keysym.Sym == sdl.K_ESCAPE {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r *Renderer) renderBase() {
+	p := &r.params.proj
+	bi, bj := p.BaseVertices()
+	checkGfx(gfx.FilledPolygonColor(r.renderer, bi, bj, r.params.baseColor))
+
+	for _, l := range p.BaseGridLines() {
+		checkGfx(gfx.LineColor(r.renderer, l[0], l[1], l[2], l[3],
+			r.params.baseLineColor))
+	}
+}
+
+func (r *Renderer) renderVoxel(vi, vj, fi, fj []int16, colors []sdl.Color) {
+	for f, vIdx := range nmms.VoxelFaces {
+		for k, v := range vIdx {
+			fi[k], fj[k] = vi[v], vj[v]
+		}
+		checkGfx(gfx.FilledPolygonColor(r.renderer, fi, fj, colors[f]))
+	}
+}
+
+func (r *Renderer) renderMatrix(m *nmms.Matrix) {
+	var fi, fj = make([]int16, 4), make([]int16, 4)
+	r.params.proj.VisitMatrix(m, func(vi, vj []int16) {
+		r.renderVoxel(vi, vj, fi, fj, r.params.fullCellColors)
+	})
+}
+
+func (r *Renderer) renderBots(bots []nmms.Nanobot) {
+	var vi, vj = make([]int16, 7), make([]int16, 7)
+	var fi, fj = make([]int16, 4), make([]int16, 4)
+	for _, b := range bots {
+		r.params.proj.ProjectVoxel(b.Pos.X, b.Pos.Y, b.Pos.Z, vi, vj, true)
+		r.renderVoxel(vi, vj, fi, fj, r.params.botCellColors)
+	}
+}
+
+func (r *Renderer) Update(n *nmms.NmmSystem) bool {
+	r.renderer.SetDrawColor(0, 0, 0, 255)
+	r.renderer.Clear()
+	r.renderBase()
+	r.renderMatrix(&n.Mat)
+	r.renderBots(n.Bots)
+	r.renderer.Present()
+	return r.shouldContinue()
+}
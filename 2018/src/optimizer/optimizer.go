@@ -0,0 +1,129 @@
+// Package optimizer rewrites Traces for the Nanobot Matter Manipulation System
+// into equivalent Traces that use less Energy.
+package optimizer
+
+import (
+	"fmt"
+	"io"
+
+	"nmms"
+)
+
+// Step holds the Commands executed in a time-step by the Nanobots with the
+// corresponding IDs.
+type Step struct {
+	Bids []int
+	Cmds []nmms.Command
+	// Grounded is true if all the Full voxels are grounded after the
+	// time-step.
+	Grounded bool
+}
+
+// Trace is a Trace split into time-steps.
+type Trace struct {
+	// The resolution of the Matrix.
+	Res   int
+	Steps []Step
+}
+
+// Commands returns the Commands of the Trace in order.
+func (t *Trace) Commands() []nmms.Command {
+	var cmds []nmms.Command
+	for _, s := range t.Steps {
+		cmds = append(cmds, s.Cmds...)
+	}
+	return cmds
+}
+
+// Pass rewrites a Trace into another one that is hopefully cheaper.
+type Pass struct {
+	Name string
+	Run  func(t *Trace) *Trace
+}
+
+// DefaultPasses are the Passes run by Optimize, in order.
+var DefaultPasses = []Pass{
+	{"merge-moves", mergeMoves},
+	{"drop-flip-pairs", dropFlipPairs},
+	{"narrow-high", narrowHigh},
+	{"drop-waits", dropWaits},
+}
+
+// newSystem returns a system with the initial Nanobots and an empty Matrix
+// with the given resolution.
+func newSystem(res int) (*nmms.NmmSystem, error) {
+	m, err := nmms.NewMatrix(res)
+	if err != nil {
+		return nil, err
+	}
+	return &nmms.NmmSystem{Mat: *m, Bots: nmms.InitialBots()}, nil
+}
+
+// simulate executes the Commands on an empty Matrix with the given
+// resolution, splitting them into time-steps.
+func simulate(res int, cmds []nmms.Command) (*Trace, *nmms.NmmSystem, error) {
+	nSys, err := newSystem(res)
+	if err != nil {
+		return nil, nil, err
+	}
+	nSys.Trc.SetCommands(cmds)
+	t := &Trace{Res: res}
+	for len(nSys.Bots) > 0 {
+		if nSys.Trc.IsEmpty() {
+			return nil, nil, fmt.Errorf("Trace ended at step %d with %d "+
+				"active Nanobots", nSys.Step, len(nSys.Bots))
+		}
+		s := Step{Bids: make([]int, len(nSys.Bots))}
+		for i, b := range nSys.Bots {
+			s.Bids[i] = b.Bid
+		}
+		if s.Cmds, err = nSys.Trc.PeekCommands(len(nSys.Bots)); err != nil {
+			return nil, nil, err
+		}
+		if err = nSys.ExecuteStep(); err != nil {
+			return nil, nil, err
+		}
+		s.Grounded = nSys.IsGrounded()
+		t.Steps = append(t.Steps, s)
+	}
+	if !nSys.Trc.IsEmpty() {
+		return nil, nil, fmt.Errorf("Trace has Commands after the Halt")
+	}
+	return t, nSys, nil
+}
+
+// Optimize runs the given Passes over the Trace for a Model with the given
+// resolution. The result of each Pass is simulated and kept only if it
+// produces the same Matrix using no more Energy than before.
+func Optimize(res int, cmds []nmms.Command, passes []Pass,
+	log io.Writer) ([]nmms.Command, error) {
+	t, nSys, err := simulate(res, cmds)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(log, "Initial: %d steps, Energy %d\n", len(t.Steps),
+		nSys.Energy)
+	for _, p := range passes {
+		nt := p.Run(t)
+		ot, oSys, err := simulate(res, nt.Commands())
+		if err != nil {
+			fmt.Fprintf(log, "Rejected %s: %v\n", p.Name, err)
+			continue
+		}
+		diffs, err := oSys.Mat.Differences(&nSys.Mat)
+		if err != nil || len(diffs) > 0 {
+			fmt.Fprintf(log, "Rejected %s: %d voxels differ\n", p.Name,
+				len(diffs))
+			continue
+		}
+		if oSys.Energy > nSys.Energy {
+			fmt.Fprintf(log, "Rejected %s: Energy %d > %d\n", p.Name,
+				oSys.Energy, nSys.Energy)
+			continue
+		}
+		fmt.Fprintf(log, "After %s: %d steps, Energy %d\n", p.Name,
+			len(ot.Steps), oSys.Energy)
+		t, nSys = ot, oSys
+	}
+	return t.Commands(), nil
+}
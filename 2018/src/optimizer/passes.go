@@ -0,0 +1,234 @@
+package optimizer
+
+import (
+	"nmms"
+)
+
+func isWait(c nmms.Command) bool {
+	_, ok := c.(*nmms.WaitCmd)
+	return ok
+}
+
+func isFlip(c nmms.Command) bool {
+	_, ok := c.(*nmms.FlipCmd)
+	return ok
+}
+
+func copyStep(s *Step) Step {
+	return Step{
+		Bids:     append([]int(nil), s.Bids...),
+		Cmds:     append([]nmms.Command(nil), s.Cmds...),
+		Grounded: s.Grounded,
+	}
+}
+
+func copySteps(t *Trace) []Step {
+	steps := make([]Step, len(t.Steps))
+	for i := range t.Steps {
+		steps[i] = copyStep(&t.Steps[i])
+	}
+	return steps
+}
+
+func sameBids(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// soleMover returns the index of the only Command in the time-step that is
+// not a Wait, if that Command is an SMove.
+func soleMover(s *Step) (int, bool) {
+	idx := -1
+	for i, c := range s.Cmds {
+		if isWait(c) {
+			continue
+		}
+		if _, ok := c.(*nmms.SMoveCmd); !ok || idx >= 0 {
+			return -1, false
+		}
+		idx = i
+	}
+	return idx, idx >= 0
+}
+
+// combineMoves returns a single Command moving a Nanobot like the first
+// SMove followed by the second one, if there is one.
+func combineMoves(a, b *nmms.SMoveCmd) (nmms.Command, bool) {
+	la, lb := nmms.MLen(&a.LLD), nmms.MLen(&b.LLD)
+	sameAxis := (a.LLD.X != 0) == (b.LLD.X != 0) &&
+		(a.LLD.Y != 0) == (b.LLD.Y != 0)
+	switch {
+	case sameAxis:
+		sum := a.LLD.Add(&b.LLD)
+		if nmms.MLen(&sum) == 0 {
+			return new(nmms.WaitCmd), true
+		}
+		if nmms.MLen(&sum) <= 15 {
+			return &nmms.SMoveCmd{LLD: sum}, true
+		}
+	case la <= 5 && lb <= 5:
+		return &nmms.LMoveCmd{SLD1: a.LLD, SLD2: b.LLD}, true
+	}
+	return nil, false
+}
+
+// canReplace checks whether the time-step, with the Command of the Nanobot at
+// the given index replaced by the given move, can be executed by the system.
+func canReplace(nSys *nmms.NmmSystem, s *Step, idx int, c nmms.Command) bool {
+	cmds := append([]nmms.Command(nil), s.Cmds...)
+	cmds[idx] = c
+	return nSys.CheckMoves(cmds) == nil
+}
+
+// mergeMoves merges a time-step where a Nanobot does an SMove while the others
+// Wait into the previous time-step, if the Nanobot also does an SMove there.
+// The two SMoves become an SMove or an LMove, saving a time-step. The merged
+// time-step is checked against the state of the system before it, which is
+// kept by executing each time-step once no more are merged into it.
+func mergeMoves(t *Trace) *Trace {
+	nSys, err := newSystem(t.Res)
+	nt := &Trace{Res: t.Res}
+	for i := range t.Steps {
+		s := &t.Steps[i]
+		n := len(nt.Steps)
+		if n > 0 && err == nil && sameBids(nt.Steps[n-1].Bids, s.Bids) {
+			prev := &nt.Steps[n-1]
+			if j, ok := soleMover(s); ok {
+				if pm, ok := prev.Cmds[j].(*nmms.SMoveCmd); ok {
+					sm := s.Cmds[j].(*nmms.SMoveCmd)
+					c, ok := combineMoves(pm, sm)
+					if ok && canReplace(nSys, prev, j, c) {
+						prev.Cmds[j] = c
+						prev.Grounded = s.Grounded
+						continue
+					}
+				}
+			}
+		}
+		if n > 0 && err == nil {
+			// The previous time-step is final. If it cannot be executed, the
+			// state of the system is lost and no more time-steps are merged.
+			nSys.Trc.SetCommands(nt.Steps[n-1].Cmds)
+			err = nSys.ExecuteStep()
+		}
+		nt.Steps = append(nt.Steps, copyStep(s))
+	}
+	return nt
+}
+
+// dropFlipPairs replaces a Flip to High harmonics and the following Flip back
+// to Low harmonics with Waits, if all the time-steps in between leave the
+// Full voxels grounded.
+func dropFlipPairs(t *Trace) *Trace {
+	steps := copySteps(t)
+	high := false
+	onStep, onIdx := -1, -1
+	for i := range steps {
+		for j, c := range steps[i].Cmds {
+			if !isFlip(c) {
+				continue
+			}
+			high = !high
+			if high {
+				onStep, onIdx = i, j
+				continue
+			}
+			needed := false
+			for k := onStep; k < i; k++ {
+				if !steps[k].Grounded {
+					needed = true
+					break
+				}
+			}
+			if !needed {
+				steps[onStep].Cmds[onIdx] = new(nmms.WaitCmd)
+				steps[i].Cmds[j] = new(nmms.WaitCmd)
+			}
+		}
+	}
+	return &Trace{Res: t.Res, Steps: steps}
+}
+
+// flipInStep replaces a Wait in the time-step with a Flip and reports whether
+// it could do so.
+func flipInStep(s *Step) bool {
+	for i, c := range s.Cmds {
+		if isWait(c) {
+			s.Cmds[i] = new(nmms.FlipCmd)
+			return true
+		}
+	}
+	return false
+}
+
+// flipStep returns a time-step where the first Nanobot Flips while the
+// others Wait.
+func flipStep(bids []int, grounded bool) Step {
+	s := Step{Bids: append([]int(nil), bids...), Grounded: grounded}
+	s.Cmds = make([]nmms.Command, len(bids))
+	s.Cmds[0] = new(nmms.FlipCmd)
+	for i := 1; i < len(bids); i++ {
+		s.Cmds[i] = new(nmms.WaitCmd)
+	}
+	return s
+}
+
+// narrowHigh moves the Flips so that the harmonics are High only after the
+// time-steps that leave ungrounded Full voxels. A Flip replaces a Wait where
+// possible, else it gets a time-step of its own. The time-steps left with only
+// Waits by the old Flips are dropped.
+func narrowHigh(t *Trace) *Trace {
+	steps := copySteps(t)
+	for i := range steps {
+		for j, c := range steps[i].Cmds {
+			if isFlip(c) {
+				steps[i].Cmds[j] = new(nmms.WaitCmd)
+			}
+		}
+	}
+	nt := &Trace{Res: t.Res}
+	for i := range steps {
+		s := steps[i]
+		needHigh := !s.Grounded
+		wasHigh := i > 0 && !steps[i-1].Grounded
+		switch {
+		case needHigh && !wasHigh:
+			// The harmonics must be High by the end of this time-step.
+			if !flipInStep(&s) {
+				nt.Steps = append(nt.Steps, flipStep(s.Bids, true))
+			}
+		case !needHigh && wasHigh:
+			// The harmonics can go Low at the end of this time-step, so the
+			// Flip goes in it or else in a time-step of its own after it.
+			if !flipInStep(&s) && i+1 < len(steps) {
+				nt.Steps = append(nt.Steps, s)
+				s = flipStep(steps[i+1].Bids, true)
+			}
+		}
+		nt.Steps = append(nt.Steps, s)
+	}
+	return dropWaits(nt)
+}
+
+// dropWaits removes the time-steps where all the Nanobots Wait.
+func dropWaits(t *Trace) *Trace {
+	nt := &Trace{Res: t.Res}
+	for i := range t.Steps {
+		s := &t.Steps[i]
+		allWait := true
+		for _, c := range s.Cmds {
+			allWait = allWait && isWait(c)
+		}
+		if !allWait {
+			nt.Steps = append(nt.Steps, copyStep(s))
+		}
+	}
+	return nt
+}
@@ -0,0 +1,114 @@
+// Package planner contains strategies for generating Traces that make the
+// Nanobots of the Nanobot Matter Manipulation System build a target Model.
+package planner
+
+import (
+	"fmt"
+	"sort"
+
+	"nmms"
+)
+
+// Planner generates a Trace for building a target Model.
+type Planner interface {
+	// Plan returns the Commands of a Trace that builds the target Matrix,
+	// starting from an empty Matrix with a single Nanobot at the origin.
+	Plan(target *nmms.Matrix) ([]nmms.Command, error)
+}
+
+var planners = map[string]Planner{
+	"sweep": &SweepPlanner{},
+}
+
+// DefaultName is the name of the Planner to use if none is specified.
+const DefaultName = "sweep"
+
+// Names returns the names of the available Planners.
+func Names() []string {
+	names := make([]string, 0, len(planners))
+	for n := range planners {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the Planner with the given name.
+func Get(name string) (Planner, error) {
+	p, ok := planners[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown Planner \"%s\" (available: %v)", name,
+			Names())
+	}
+	return p, nil
+}
+
+// Verify executes the given Trace using the simulator and checks that it
+// builds the target Matrix, returning the Energy used by the Trace.
+func Verify(target *nmms.Matrix, cmds []nmms.Command) (int, error) {
+	m, err := nmms.NewMatrix(target.Resolution())
+	if err != nil {
+		return 0, err
+	}
+	var nSys nmms.NmmSystem
+	nSys.Mat = *m
+	nSys.Bots = nmms.InitialBots()
+	nSys.Trc.SetCommands(cmds)
+	if err = nSys.Run(); err != nil {
+		return 0, err
+	}
+	if !nSys.Trc.IsEmpty() {
+		return 0, fmt.Errorf("Trace has Commands after the Halt")
+	}
+	diffs, err := nSys.Mat.Differences(target)
+	if err != nil {
+		return 0, err
+	}
+	if len(diffs) > 0 {
+		return 0, fmt.Errorf("%d voxels differ from the target, e.g. %v",
+			len(diffs), &diffs[0])
+	}
+	return nSys.Energy, nil
+}
+
+// botPath accumulates the Commands for a single Nanobot, keeping track of its
+// position.
+type botPath struct {
+	pos  nmms.Coordinate
+	cmds []nmms.Command
+}
+
+func (b *botPath) emit(c nmms.Command) {
+	b.cmds = append(b.cmds, c)
+}
+
+// moveAlong moves the Nanobot by the given distance along a single axis,
+// using as few SMoves as possible. The path is assumed to be clear.
+func (b *botPath) moveAlong(axis *int, d int, unit func(int) nmms.Coordinate) {
+	const maxLLD = 15
+	for d != 0 {
+		step := d
+		if step > maxLLD {
+			step = maxLLD
+		} else if step < -maxLLD {
+			step = -maxLLD
+		}
+		b.emit(&nmms.SMoveCmd{LLD: unit(step)})
+		*axis += step
+		d -= step
+	}
+}
+
+// moveTo moves the Nanobot to the given Coordinate, along the Y-axis first
+// when going up and last when going down. The path is assumed to be clear.
+func (b *botPath) moveTo(c nmms.Coordinate) {
+	xUnit := func(d int) nmms.Coordinate { return nmms.Coordinate{X: d} }
+	yUnit := func(d int) nmms.Coordinate { return nmms.Coordinate{Y: d} }
+	zUnit := func(d int) nmms.Coordinate { return nmms.Coordinate{Z: d} }
+	if c.Y > b.pos.Y {
+		b.moveAlong(&b.pos.Y, c.Y-b.pos.Y, yUnit)
+	}
+	b.moveAlong(&b.pos.X, c.X-b.pos.X, xUnit)
+	b.moveAlong(&b.pos.Z, c.Z-b.pos.Z, zUnit)
+	b.moveAlong(&b.pos.Y, c.Y-b.pos.Y, yUnit)
+}
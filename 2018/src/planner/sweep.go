@@ -0,0 +1,148 @@
+package planner
+
+import (
+	"fmt"
+
+	"nmms"
+)
+
+// SweepPlanner uses a single Nanobot to build the target layer by layer from
+// the bottom, sweeping across each layer just above it and filling the voxels
+// below. It flips the harmonics to High only while there are filled voxels
+// that are not yet grounded.
+type SweepPlanner struct{}
+
+func (s *SweepPlanner) Plan(target *nmms.Matrix) ([]nmms.Command, error) {
+	var b botPath
+	var g groundedSet
+	high := false
+	flip := func() {
+		b.emit(new(nmms.FlipCmd))
+		high = !high
+	}
+
+	bbMin, bbMax := target.BoundingBox()
+	for y := 0; y <= bbMax.Y; y++ {
+		forward := true
+		for z := bbMin.Z; z <= bbMax.Z; z++ {
+			x0, x1, ok := rowExtent(target, bbMin.X, bbMax.X, y, z)
+			if !ok {
+				continue
+			}
+			if !forward {
+				x0, x1 = x1, x0
+			}
+			dx := 1
+			if x1 < x0 {
+				dx = -1
+			}
+			for x := x0; ; x += dx {
+				if target.IsFull(x, y, z) {
+					b.moveTo(nmms.Coordinate{X: x, Y: y + 1, Z: z})
+					c := nmms.Coordinate{X: x, Y: y, Z: z}
+					grounded := g.add(c)
+					if !grounded && !high {
+						flip()
+					}
+					b.emit(&nmms.FillCmd{ND: nmms.Coordinate{Y: -1}})
+					if grounded && high && g.numFloating == 0 {
+						flip()
+					}
+				}
+				if x == x1 {
+					break
+				}
+			}
+			forward = !forward
+		}
+	}
+	if high {
+		return nil, fmt.Errorf("%d voxels of the target are not grounded",
+			g.numFloating)
+	}
+
+	b.moveTo(nmms.Coordinate{X: b.pos.X, Y: bbMax.Y + 1, Z: b.pos.Z})
+	b.moveTo(nmms.Coordinate{})
+	b.emit(new(nmms.HaltCmd))
+	return b.cmds, nil
+}
+
+// rowExtent returns the X-coordinates of the first and the last Full voxels
+// in the given row of the target.
+func rowExtent(target *nmms.Matrix, minX, maxX, y, z int) (int, int, bool) {
+	x0, x1 := -1, -1
+	for x := minX; x <= maxX; x++ {
+		if target.IsFull(x, y, z) {
+			if x0 < 0 {
+				x0 = x
+			}
+			x1 = x
+		}
+	}
+	return x0, x1, x0 >= 0
+}
+
+// groundedSet tracks the grounded-ness of a growing set of Full voxels using
+// a disjoint-set forest.
+type groundedSet struct {
+	parent      map[nmms.Coordinate]nmms.Coordinate
+	size        map[nmms.Coordinate]int
+	grounded    map[nmms.Coordinate]bool
+	numFloating int
+}
+
+func (g *groundedSet) find(c nmms.Coordinate) nmms.Coordinate {
+	for g.parent[c] != c {
+		g.parent[c] = g.parent[g.parent[c]]
+		c = g.parent[c]
+	}
+	return c
+}
+
+func (g *groundedSet) union(c, d nmms.Coordinate) {
+	rc, rd := g.find(c), g.find(d)
+	if rc == rd {
+		return
+	}
+	if g.size[rc] < g.size[rd] {
+		rc, rd = rd, rc
+	}
+	gc, gd := g.grounded[rc], g.grounded[rd]
+	if gc != gd {
+		// The floating set is now grounded.
+		if gc {
+			g.numFloating -= g.size[rd]
+		} else {
+			g.numFloating -= g.size[rc]
+		}
+	}
+	g.parent[rd] = rc
+	g.size[rc] += g.size[rd]
+	g.grounded[rc] = gc || gd
+}
+
+// add adds a Full voxel to the set and reports whether it is grounded.
+func (g *groundedSet) add(c nmms.Coordinate) bool {
+	if g.parent == nil {
+		g.parent = make(map[nmms.Coordinate]nmms.Coordinate)
+		g.size = make(map[nmms.Coordinate]int)
+		g.grounded = make(map[nmms.Coordinate]bool)
+	}
+	g.parent[c] = c
+	g.size[c] = 1
+	g.grounded[c] = c.Y == 0
+	if c.Y != 0 {
+		g.numFloating++
+	}
+	nbrs := []nmms.Coordinate{
+		{X: c.X - 1, Y: c.Y, Z: c.Z}, {X: c.X + 1, Y: c.Y, Z: c.Z},
+		{X: c.X, Y: c.Y - 1, Z: c.Z}, {X: c.X, Y: c.Y + 1, Z: c.Z},
+		{X: c.X, Y: c.Y, Z: c.Z - 1}, {X: c.X, Y: c.Y, Z: c.Z + 1},
+	}
+	for _, n := range nbrs {
+		if _, ok := g.parent[n]; ok {
+			g.union(c, n)
+		}
+	}
+	return g.grounded[g.find(c)]
+}
@@ -0,0 +1,25 @@
+// Usage: go run assembler.go /path/to/file.asm /path/to/file.nbt
+// (or "go run assembler.go -d /path/to/file.nbt" to disassemble a Trace).
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"nmms"
+)
+
+func main() {
+	if len(os.Args) == 3 && os.Args[1] == "-d" {
+		encCmds, err := ioutil.ReadFile(os.Args[2])
+		nmms.Check(err)
+		nmms.Check(nmms.Disassemble(os.Stdout, encCmds))
+		return
+	}
+	if len(os.Args) < 3 {
+		nmms.ExitWithErrorMsg("Missing assembly and Trace argument.")
+	}
+	fmt.Printf("Assembling \"%s\" into \"%s\".\n", os.Args[1], os.Args[2])
+	nmms.Check(nmms.AssembleFile(os.Args[1], os.Args[2]))
+}
@@ -1,4 +1,9 @@
-// Usage: go run replayer.go /path/to/file.nbt /path/to/file.mdl
+// Usage: go run replayer.go [OPTION] /path/to/file.nbt /path/to/file.mdl
+// where OPTION is "-d" to step through the Trace using a debugger, "-p
+// /path/to/out.png" to save a picture of its final state or "-g
+// /path/to/out.gif" to save an animation of it, instead of showing it in a
+// viewer. A Trace path of "-" reads the Trace from the standard input, except
+// with "-d", as the debugger reads its commands from the standard input.
 package main
 
 import (
@@ -6,32 +11,90 @@ import (
 	"os"
 
 	"nmms"
+	"nmms/viewer"
+)
+
+const (
+	pngWidth  = 1024
+	pngHeight = 768
+	gifWidth  = 480
+	gifHeight = 360
 )
 
 func main() {
-	if len(os.Args) < 3 {
+	args := os.Args[1:]
+	debug := len(args) > 0 && args[0] == "-d"
+	if debug {
+		args = args[1:]
+	}
+	pngPath, gifPath := "", ""
+	if len(args) > 1 && args[0] == "-p" {
+		pngPath, args = args[1], args[2:]
+	} else if len(args) > 1 && args[0] == "-g" {
+		gifPath, args = args[1], args[2:]
+	}
+	if len(args) < 2 {
 		nmms.ExitWithErrorMsg("Missing Trace and target-Model argument.")
 	}
+	if debug && args[0] == "-" {
+		nmms.ExitWithErrorMsg("Cannot read the Trace from the standard " +
+			"input with \"-d\", which reads debugger-commands from it.")
+	}
 	var nSys nmms.NmmSystem
-	nSys.Bots = make([]nmms.Nanobot, 1)
-	nSys.Bots[0].Pos = nmms.Coordinate{}
+	nSys.Bots = nmms.InitialBots()
 
-	fmt.Printf("Reading Trace file \"%s\".\n", os.Args[1])
-	nmms.Check(nSys.Trc.ReadFromFile(os.Args[1]))
-	fmt.Printf("Reading Model file \"%s\".\n", os.Args[2])
-	nmms.Check(nSys.Mat.ReadFromFile(os.Args[2]))
+	fmt.Printf("Reading Trace file \"%s\".\n", args[0])
+	nmms.Check(nSys.Trc.ReadFromFile(args[0]))
+	defer nSys.Trc.Close()
+	fmt.Printf("Reading Model file \"%s\".\n", args[1])
+	nmms.Check(nSys.Mat.ReadFromFile(args[1]))
 	nSys.Mat.Clear()
 	res := nSys.Mat.Resolution()
 	fmt.Printf("Resolution=%d\n", res)
 
-	var viewer nmms.Renderer
-	nmms.Check(viewer.Init(&nSys))
-	for viewer.Update(&nSys) {
+	if debug {
+		nmms.Check(nmms.NewDebugger(&nSys).Run(os.Stdin, os.Stdout))
+		fmt.Printf("Final Energy: %d\n", nSys.Energy)
+		return
+	}
+
+	if pngPath != "" || gifPath != "" {
+		var rec *nmms.GifRecorder
+		if gifPath != "" {
+			rec = nmms.NewGifRecorder(nmms.ObliqueView, gifWidth, gifHeight)
+			rec.Record(&nSys)
+		}
+		for len(nSys.Bots) > 0 && !nSys.Trc.IsEmpty() {
+			if err := nSys.ExecuteStep(); err != nil {
+				fmt.Printf("ERROR: %v\n", err)
+				break
+			}
+			if rec != nil {
+				rec.Record(&nSys)
+			}
+		}
+		fmt.Printf("Final Energy: %d\n", nSys.Energy)
+		nSys.PrintEnergyUse()
+		if rec != nil {
+			fmt.Printf("Writing animation \"%s\".\n", gifPath)
+			nmms.Check(rec.WriteToFile(gifPath, &nSys))
+		} else {
+			fmt.Printf("Writing picture \"%s\".\n", pngPath)
+			nmms.Check(nmms.WriteImageFile(pngPath, &nSys.Mat, nSys.Bots,
+				nmms.ObliqueView, pngWidth, pngHeight))
+		}
+		return
+	}
+
+	var v viewer.Renderer
+	nmms.Check(v.Init(&nSys))
+	for v.Update(&nSys) {
 		if err := nSys.ExecuteStep(); err != nil {
 			fmt.Printf("ERROR: %v\n", err)
 			break
 		}
 	}
 	fmt.Printf("Final Energy: %d\n", nSys.Energy)
-	viewer.Quit()
+	nSys.PrintEnergyUse()
+	v.Quit()
 }
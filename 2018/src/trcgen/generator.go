@@ -0,0 +1,37 @@
+// Usage: go run generator.go /path/to/target.mdl /path/to/file.nbt [planner]
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"nmms"
+	"planner"
+)
+
+func main() {
+	if len(os.Args) < 3 {
+		nmms.ExitWithErrorMsg("Missing target-Model and Trace argument.")
+	}
+	name := planner.DefaultName
+	if len(os.Args) > 3 {
+		name = os.Args[3]
+	}
+	p, err := planner.Get(name)
+	nmms.Check(err)
+
+	fmt.Printf("Reading Model file \"%s\".\n", os.Args[1])
+	var target nmms.Matrix
+	nmms.Check(target.ReadFromFile(os.Args[1]))
+	fmt.Printf("Resolution=%d\n", target.Resolution())
+
+	fmt.Printf("Planning with \"%s\".\n", name)
+	cmds, err := p.Plan(&target)
+	nmms.Check(err)
+	energy, err := planner.Verify(&target, cmds)
+	nmms.Check(err)
+	fmt.Printf("Commands: %d, Energy: %d\n", len(cmds), energy)
+
+	fmt.Printf("Writing Trace file \"%s\".\n", os.Args[2])
+	nmms.Check(nmms.WriteTraceFile(os.Args[2], cmds))
+}
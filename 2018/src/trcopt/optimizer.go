@@ -0,0 +1,38 @@
+// Usage: go run optimizer.go /path/to/file.mdl /path/to/in.nbt /path/to/out.nbt
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"nmms"
+	"optimizer"
+)
+
+func main() {
+	if len(os.Args) < 4 {
+		nmms.ExitWithErrorMsg("Missing Model, input Trace or output Trace " +
+			"argument.")
+	}
+	fmt.Printf("Reading Model file \"%s\".\n", os.Args[1])
+	var target nmms.Matrix
+	nmms.Check(target.ReadFromFile(os.Args[1]))
+
+	fmt.Printf("Reading Trace file \"%s\".\n", os.Args[2])
+	var trc nmms.Tracer
+	nmms.Check(trc.ReadFromFile(os.Args[2]))
+	defer trc.Close()
+	var cmds []nmms.Command
+	for !trc.IsEmpty() {
+		c, err := trc.TakeCommands(1)
+		nmms.Check(err)
+		cmds = append(cmds, c...)
+	}
+
+	cmds, err := optimizer.Optimize(target.Resolution(), cmds,
+		optimizer.DefaultPasses, os.Stdout)
+	nmms.Check(err)
+
+	fmt.Printf("Writing Trace file \"%s\".\n", os.Args[3])
+	nmms.Check(nmms.WriteTraceFile(os.Args[3], cmds))
+}
@@ -0,0 +1,149 @@
+// Usage: go run stats.go /path/to/file.mdl /path/to/file.nbt [...]
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"nmms"
+)
+
+type traceStats struct {
+	path         string
+	err          error
+	steps        int
+	energy       int
+	optEnergy    int
+	cmdCounts    map[string]int
+	sMoveDist    int
+	lMoveDist    int
+	peakBots     int
+	highSteps    int
+	neededHighs  int
+	numFlips     int
+	cmdsExecuted int
+}
+
+// harmonicsCost returns the Energy used by the harmonics in one time-step.
+func harmonicsCost(res int, high bool) int {
+	if high {
+		return 30 * res * res * res
+	}
+	return 3 * res * res * res
+}
+
+// collectStats replays the given Trace headlessly. Besides the actual Energy
+// used, it estimates the Energy that would have been used with the harmonics
+// set to High only after the time-steps that leave ungrounded voxels. This is
+// a lower bound, since it assumes that a Flip can always be fitted into the
+// time-step that leaves the first ungrounded voxel.
+func collectStats(path string, model *nmms.Matrix) *traceStats {
+	ts := &traceStats{path: path, cmdCounts: make(map[string]int)}
+	var nSys nmms.NmmSystem
+	nSys.Bots = nmms.InitialBots()
+	if ts.err = nSys.Trc.ReadFromFile(path); ts.err != nil {
+		return ts
+	}
+	defer nSys.Trc.Close()
+	nSys.Mat = *model.Copy()
+	nSys.Mat.Clear()
+	res := nSys.Mat.Resolution()
+
+	needHigh := false
+	for len(nSys.Bots) > 0 && !nSys.Trc.IsEmpty() {
+		ts.peakBots = nmms.IMax(ts.peakBots, len(nSys.Bots))
+		cmds, err := nSys.Trc.PeekCommands(len(nSys.Bots))
+		if err != nil {
+			ts.err = err
+			break
+		}
+		if nSys.HighHarmonics {
+			ts.highSteps++
+		}
+		if needHigh {
+			ts.neededHighs++
+		}
+		e0 := nSys.Energy
+		high := nSys.HighHarmonics
+		if err = nSys.ExecuteStep(); err != nil {
+			ts.err = err
+			break
+		}
+		ts.cmdsExecuted += len(cmds)
+		for _, c := range cmds {
+			ts.cmdCounts[nmms.CmdName(c)]++
+			switch mc := c.(type) {
+			case *nmms.SMoveCmd:
+				ts.sMoveDist += nmms.MLen(&mc.LLD)
+			case *nmms.LMoveCmd:
+				ts.lMoveDist += nmms.MLen(&mc.SLD1) + nmms.MLen(&mc.SLD2)
+			case *nmms.FlipCmd:
+				ts.numFlips++
+			}
+		}
+		ts.optEnergy += nSys.Energy - e0 - harmonicsCost(res, high) +
+			harmonicsCost(res, needHigh)
+		needHigh = !nSys.IsGrounded()
+	}
+	ts.steps = nSys.Step
+	ts.energy = nSys.Energy
+	return ts
+}
+
+func printStats(ts *traceStats) {
+	fmt.Printf("Trace \"%s\":\n", ts.path)
+	if ts.err != nil {
+		fmt.Printf("  ERROR: %v\n", ts.err)
+	}
+	fmt.Printf("  Steps: %d, Commands: %d, Peak Nanobots: %d\n", ts.steps,
+		ts.cmdsExecuted, ts.peakBots)
+	names := make([]string, 0, len(ts.cmdCounts))
+	for n := range ts.cmdCounts {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Printf("    %-8s %10d\n", n, ts.cmdCounts[n])
+	}
+	fmt.Printf("  SMove distance: %d, LMove distance: %d\n", ts.sMoveDist,
+		ts.lMoveDist)
+	fmt.Printf("  High harmonics: %d steps (%d needed), %d Flips\n",
+		ts.highSteps, ts.neededHighs, ts.numFlips)
+	fmt.Printf("  Energy: %d (%d with optimal Flips)\n", ts.energy,
+		ts.optEnergy)
+}
+
+func main() {
+	if len(os.Args) < 3 {
+		nmms.ExitWithErrorMsg("Missing target-Model and Trace arguments.")
+	}
+	var model nmms.Matrix
+	fmt.Printf("Reading Model file \"%s\".\n", os.Args[1])
+	nmms.Check(model.ReadFromFile(os.Args[1]))
+
+	var all []*traceStats
+	for _, p := range os.Args[2:] {
+		ts := collectStats(p, &model)
+		printStats(ts)
+		all = append(all, ts)
+	}
+	if len(all) < 2 {
+		return
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if (all[i].err == nil) != (all[j].err == nil) {
+			return all[i].err == nil
+		}
+		return all[i].energy < all[j].energy
+	})
+	fmt.Printf("Ranking:\n")
+	for i, ts := range all {
+		status := ""
+		if ts.err != nil {
+			status = " (FAILED)"
+		}
+		fmt.Printf("  %2d. %14d %s%s\n", i+1, ts.energy, ts.path, status)
+	}
+}
@@ -0,0 +1,61 @@
+// Usage: go run validator.go /path/to/file.nbt /path/to/file.mdl
+// (A Trace path of "-" reads the Trace from the standard input.)
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"nmms"
+)
+
+const (
+	maxDiffsShown = 10
+)
+
+func main() {
+	if len(os.Args) < 3 {
+		nmms.ExitWithErrorMsg("Missing Trace and target-Model argument.")
+	}
+	var nSys nmms.NmmSystem
+	nSys.Bots = nmms.InitialBots()
+
+	fmt.Printf("Reading Trace file \"%s\".\n", os.Args[1])
+	nmms.Check(nSys.Trc.ReadFromFile(os.Args[1]))
+	defer nSys.Trc.Close()
+	fmt.Printf("Reading Model file \"%s\".\n", os.Args[2])
+	var target nmms.Matrix
+	nmms.Check(target.ReadFromFile(os.Args[2]))
+	nSys.Mat = *target.Copy()
+	nSys.Mat.Clear()
+	fmt.Printf("Resolution=%d\n", nSys.Mat.Resolution())
+
+	pass := true
+	if err := nSys.Run(); err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		pass = false
+	}
+	fmt.Printf("Steps: %d\n", nSys.Step)
+	fmt.Printf("Final Energy: %d\n", nSys.Energy)
+	nSys.PrintEnergyUse()
+
+	diffs, err := nSys.Mat.Differences(&target)
+	nmms.Check(err)
+	if len(diffs) > 0 {
+		fmt.Printf("Mismatched voxels: %d\n", len(diffs))
+		for i, c := range diffs {
+			if i >= maxDiffsShown {
+				fmt.Printf("  ...\n")
+				break
+			}
+			fmt.Printf("  %v\n", &c)
+		}
+		pass = false
+	}
+
+	if !pass {
+		fmt.Printf("FAIL\n")
+		os.Exit(1)
+	}
+	fmt.Printf("PASS\n")
+}
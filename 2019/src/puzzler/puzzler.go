@@ -0,0 +1,31 @@
+// Usage: go run puzzler.go /path/to/puzzle.cond [/path/to/task.desc]
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"wwabr"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		wwabr.ExitWithErrorMsg("Missing puzzle file-path argument.")
+	}
+	fmt.Printf("Reading puzzle file \"%s\".\n", os.Args[1])
+	pz, err := wwabr.NewPuzzleFromFile(os.Args[1])
+	wwabr.Check(err)
+
+	desc, err := pz.Generate()
+	wwabr.Check(err)
+	descPath := strings.TrimSuffix(os.Args[1], filepath.Ext(os.Args[1])) +
+		".desc"
+	if len(os.Args) > 2 {
+		descPath = os.Args[2]
+	}
+	fmt.Printf("Writing task to \"%s\".\n", descPath)
+	wwabr.Check(ioutil.WriteFile(descPath, []byte(desc+"\n"), 0644))
+}
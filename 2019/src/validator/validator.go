@@ -0,0 +1,60 @@
+// Usage: go run validator.go [-p /path/to/out.png] [-d] /path/to/prob.desc
+// /path/to/prob.sol
+// (The "-p" option saves a picture of the final Map with the paths of the
+// workers, while "-d" shows the cells that the solution fails to wrap.)
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"wwabr"
+)
+
+func main() {
+	args := os.Args[1:]
+	var pngPath string
+	showDiff := false
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if args[0] == "-d" {
+			showDiff, args = true, args[1:]
+		} else if args[0] == "-p" && len(args) > 1 {
+			pngPath, args = args[1], args[2:]
+		} else {
+			wwabr.ExitWithErrorMsg(fmt.Sprintf("Unknown option \"%s\".",
+				args[0]))
+		}
+	}
+	if len(args) < 2 {
+		wwabr.ExitWithErrorMsg(
+			"Missing problem-description or solution file-path argument.")
+	}
+	fmt.Printf("Reading problem-description file \"%s\".\n", args[0])
+	wSys, err := wwabr.NewFromFile(args[0])
+	wwabr.Check(err)
+	fmt.Printf("Reading solution file \"%s\".\n", args[1])
+	solBytes, err := ioutil.ReadFile(args[1])
+	wwabr.Check(err)
+	sol := strings.TrimSpace(string(solBytes))
+
+	sim, vErr := wwabr.ValidateSim(wSys, sol)
+	if sim == nil {
+		wwabr.Check(vErr)
+	}
+	if pngPath != "" {
+		fmt.Printf("Writing picture to \"%s\".\n", pngPath)
+		wwabr.Check(wwabr.WriteMapImage(pngPath, sim.Map, sim.Paths()))
+	}
+	if showDiff {
+		wwabr.WriteDiff(os.Stdout, sim.Map)
+	}
+	if vErr != nil {
+		fmt.Printf("ERROR: %v\n", vErr)
+		fmt.Printf("FAIL\n")
+		os.Exit(1)
+	}
+	fmt.Printf("Time-units: %d\n", sim.Time)
+	fmt.Printf("PASS\n")
+}
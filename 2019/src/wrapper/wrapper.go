@@ -1,19 +1,89 @@
-// Usage: go run wrapper.go /path/to/prob.desc
+// Usage: go run wrapper.go /path/to/prob.desc [/path/to/prob.sol]
+// or: go run wrapper.go -b [-j N] /path/to/probs [/path/to/summary.csv]
+// (The "-b" option solves all the problems in a directory using N goroutines,
+// by default one per CPU, keeping the better of the new and the existing
+// solution of each problem.)
 package main
 
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 
 	"wwabr"
 )
 
-func main() {
-	if len(os.Args) < 2 {
+func solveOne(args []string) {
+	if len(args) < 1 {
 		wwabr.ExitWithErrorMsg(
 			"Missing problem-description file-path argument.")
 	}
-	fmt.Printf("Reading problem-description file \"%s\".\n", os.Args[1])
-	_, err := wwabr.NewFromFile(os.Args[1])
+	fmt.Printf("Reading problem-description file \"%s\".\n", args[0])
+	wSys, err := wwabr.NewFromFile(args[0])
+	wwabr.Check(err)
+
+	sol, err := wwabr.Solve(wSys)
+	wwabr.Check(err)
+	solPath := strings.TrimSuffix(args[0], filepath.Ext(args[0])) + ".sol"
+	if len(args) > 1 {
+		solPath = args[1]
+	}
+	fmt.Printf("Writing solution with %d actions to \"%s\".\n", len(sol),
+		solPath)
+	wwabr.Check(wwabr.WriteSolutionFile(solPath, sol))
+}
+
+func solveBatch(args []string, workers int) {
+	if len(args) < 1 {
+		wwabr.ExitWithErrorMsg("Missing problems directory argument.")
+	}
+	fmt.Printf("Solving problems in \"%s\" with %d goroutines.\n", args[0],
+		workers)
+	rs, err := wwabr.SolveDir(args[0], workers, os.Stdout)
 	wwabr.Check(err)
+
+	sumPath := filepath.Join(args[0], "summary.csv")
+	if len(args) > 1 {
+		sumPath = args[1]
+	}
+	total, failed := 0, 0
+	for _, r := range rs {
+		if r.Best() == 0 {
+			failed++
+		}
+		total += r.Best()
+	}
+	fmt.Printf("Solved %d of %d problems in %d time-units.\n",
+		len(rs)-failed, len(rs), total)
+	fmt.Printf("Writing summary to \"%s\".\n", sumPath)
+	wwabr.Check(wwabr.WriteSummary(sumPath, rs))
+}
+
+func main() {
+	args := os.Args[1:]
+	batch := false
+	workers := runtime.NumCPU()
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if args[0] == "-b" {
+			batch, args = true, args[1:]
+		} else if args[0] == "-j" && len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				wwabr.ExitWithErrorMsg(fmt.Sprintf(
+					"Invalid number of goroutines \"%s\".", args[1]))
+			}
+			workers, args = n, args[2:]
+		} else {
+			wwabr.ExitWithErrorMsg(fmt.Sprintf("Unknown option \"%s\".",
+				args[0]))
+		}
+	}
+	if batch {
+		solveBatch(args, workers)
+	} else {
+		solveOne(args)
+	}
 }
@@ -0,0 +1,148 @@
+package wwabr
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BatchResult is the outcome of solving a problem in a batch.
+type BatchResult struct {
+	Problem string
+	// The time-units taken by the new solution, and by the one that was
+	// already there, or 0 if there was none or it was not valid.
+	Time, PrevTime int
+	// Whether the new solution replaced the one that was already there.
+	Updated bool
+	Err     error
+}
+
+// Best returns the time-units taken by the better of the new and the previous
+// solutions, or 0 if neither is valid.
+func (r *BatchResult) Best() int {
+	if r.Updated || r.PrevTime == 0 {
+		return r.Time
+	}
+	return r.PrevTime
+}
+
+// solutionPath returns the path of the solution file for a problem.
+func solutionPath(p string) string {
+	return strings.TrimSuffix(p, filepath.Ext(p)) + ".sol"
+}
+
+// solveProblem solves the problem in the given file, and writes the solution
+// next to it unless the solution that is already there is at least as good.
+func solveProblem(p string) *BatchResult {
+	r := &BatchResult{Problem: strings.TrimSuffix(filepath.Base(p),
+		filepath.Ext(p))}
+	wSys, err := readTask(p)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	solPath := solutionPath(p)
+	if b, err := ioutil.ReadFile(solPath); err == nil {
+		if t, err := Validate(wSys, strings.TrimSpace(string(b))); err == nil {
+			r.PrevTime = t
+		}
+	}
+	sol, err := Solve(wSys)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	if r.Time, r.Err = Validate(wSys, sol); r.Err != nil {
+		r.Time = 0
+		return r
+	}
+	if r.PrevTime == 0 || r.Time < r.PrevTime {
+		r.Err = WriteSolutionFile(solPath, sol)
+		r.Updated = r.Err == nil
+	}
+	return r
+}
+
+// SolveDir solves the problems in the ".desc" files of the given directory
+// using the given number of goroutines, keeping the better of the new and the
+// previous solutions of each problem. It logs the outcome of each problem as
+// it is solved, and returns the outcomes in the order of the problems.
+func SolveDir(dir string, workers int, log io.Writer) ([]*BatchResult, error) {
+	ps, err := filepath.Glob(filepath.Join(dir, "*.desc"))
+	if err != nil {
+		return nil, err
+	}
+	if len(ps) == 0 {
+		return nil, fmt.Errorf("No problem-descriptions in \"%s\".", dir)
+	}
+	sort.Strings(ps)
+
+	type job struct {
+		i int
+		r *BatchResult
+	}
+	jobs := make(chan int)
+	done := make(chan job)
+	for w := 0; w < iMax(workers, 1); w++ {
+		go func() {
+			for i := range jobs {
+				done <- job{i, solveProblem(ps[i])}
+			}
+		}()
+	}
+	go func() {
+		for i := range ps {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+	rs := make([]*BatchResult, len(ps))
+	for n := 1; n <= len(ps); n++ {
+		j := <-done
+		rs[j.i] = j.r
+		switch {
+		case j.r.Err != nil:
+			fmt.Fprintf(log, "[%d/%d] %s: ERROR: %v\n", n, len(ps), j.r.Problem,
+				j.r.Err)
+		case j.r.Updated:
+			fmt.Fprintf(log, "[%d/%d] %s: %d time-units (was %d).\n", n,
+				len(ps), j.r.Problem, j.r.Time, j.r.PrevTime)
+		default:
+			fmt.Fprintf(log, "[%d/%d] %s: %d time-units (kept %d).\n", n,
+				len(ps), j.r.Problem, j.r.Time, j.r.PrevTime)
+		}
+	}
+	return rs, nil
+}
+
+// WriteSummary writes the outcomes of a batch into a CSV file.
+func WriteSummary(p string, rs []*BatchResult) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"problem", "time_units", "new_time_units",
+		"prev_time_units", "updated", "error"})
+	for _, r := range rs {
+		errMsg := ""
+		if r.Err != nil {
+			errMsg = r.Err.Error()
+		}
+		w.Write([]string{r.Problem, strconv.Itoa(r.Best()),
+			strconv.Itoa(r.Time), strconv.Itoa(r.PrevTime),
+			strconv.FormatBool(r.Updated), errMsg})
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
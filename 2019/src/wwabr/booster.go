@@ -0,0 +1,61 @@
+package wwabr
+
+import (
+	"fmt"
+	"strings"
+)
+
+type BoosterCode byte
+
+const (
+	ExtensionBooster  BoosterCode = 'B'
+	FastWheelsBooster BoosterCode = 'F'
+	DrillBooster      BoosterCode = 'L'
+	MysteriousPoint   BoosterCode = 'X'
+	TeleportBooster   BoosterCode = 'R'
+	CloningBooster    BoosterCode = 'C'
+)
+
+const (
+	// The number of time-units for which a booster remains active.
+	fastWheelsDuration = 50
+	drillDuration      = 30
+)
+
+type Booster struct {
+	Code BoosterCode
+	Loc  Point
+}
+
+func (b Booster) String() string {
+	return fmt.Sprintf("%c%v", b.Code, b.Loc)
+}
+
+func isBoosterCode(c byte) bool {
+	switch BoosterCode(c) {
+	case ExtensionBooster, FastWheelsBooster, DrillBooster, MysteriousPoint,
+		TeleportBooster, CloningBooster:
+		return true
+	}
+	return false
+}
+
+func parseBoosters(s string) ([]Booster, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	t := strings.Split(s, ";")
+	bs := make([]Booster, len(t))
+	for i, v := range t {
+		v = strings.TrimSpace(v)
+		if len(v) == 0 || !isBoosterCode(v[0]) {
+			return nil, fmt.Errorf("Invalid booster \"%s\".", v)
+		}
+		p, err := parsePoint(v[1:])
+		if err != nil {
+			return nil, err
+		}
+		bs[i] = Booster{BoosterCode(v[0]), p}
+	}
+	return bs, nil
+}
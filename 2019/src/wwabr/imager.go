@@ -0,0 +1,185 @@
+package wwabr
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// The preferred size in pixels of the larger side of a Map image.
+	mapImageSize = 800
+	// Wider differences are shown as runs of unwrapped cells.
+	maxDiffWidth = 100
+)
+
+var (
+	cellColors = map[MapCell]color.RGBA{
+		InvalidCell:  {0, 0, 0, 255},
+		EmptyCell:    {255, 255, 255, 255},
+		ObstacleCell: {192, 0, 0, 255},
+		WrappedCell:  {64, 96, 224, 255},
+	}
+	pathColor  = color.RGBA{255, 224, 0, 255}
+	startColor = color.RGBA{0, 224, 0, 255}
+	endColor   = color.RGBA{255, 0, 255, 255}
+)
+
+// RenderMap renders the Map, with each cell as a square of the given size in
+// pixels, overlaid with the given paths of the workers.
+func RenderMap(m Map, paths [][]Point, cellSize int) *image.RGBA {
+	w, h := m.Width(), m.Height()
+	img := image.NewRGBA(image.Rect(0, 0, w*cellSize, h*cellSize))
+	// The Y-axis of the Map points upwards, unlike that of the image.
+	fillCell := func(p Point, c color.RGBA, inset int) {
+		for j := inset; j < cellSize-inset; j++ {
+			for i := inset; i < cellSize-inset; i++ {
+				img.SetRGBA(p.X*cellSize+i, (h-1-p.Y)*cellSize+j, c)
+			}
+		}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fillCell(Point{x, y}, cellColors[m.At(Point{x, y})], 0)
+		}
+	}
+	centre := func(p Point) (int, int) {
+		return p.X*cellSize + cellSize/2, (h-1-p.Y)*cellSize + cellSize/2
+	}
+	for _, path := range paths {
+		for k := 1; k < len(path); k++ {
+			i0, j0 := centre(path[k-1])
+			i1, j1 := centre(path[k])
+			drawLine(img, i0, j0, i1, j1, pathColor)
+		}
+	}
+	inset := cellSize / 4
+	for _, path := range paths {
+		if len(path) > 0 {
+			fillCell(path[0], startColor, inset)
+			fillCell(path[len(path)-1], endColor, inset)
+		}
+	}
+	return img
+}
+
+// drawLine draws a line between the given end-points using Bresenham's
+// algorithm.
+func drawLine(img *image.RGBA, i0, j0, i1, j1 int, c color.RGBA) {
+	di, dj := iAbs(i1-i0), -iAbs(j1-j0)
+	si, sj := 1, 1
+	if i0 > i1 {
+		si = -1
+	}
+	if j0 > j1 {
+		sj = -1
+	}
+	e := di + dj
+	for {
+		img.SetRGBA(i0, j0, c)
+		if i0 == i1 && j0 == j1 {
+			return
+		}
+		if 2*e >= dj {
+			e += dj
+			i0 += si
+		}
+		if 2*e <= di {
+			e += di
+			j0 += sj
+		}
+	}
+}
+
+// WriteMapImage writes the rendering of the Map, overlaid with the given paths
+// of the workers, into the given PNG file.
+func WriteMapImage(p string, m Map, paths [][]Point) error {
+	cellSize := iMax(mapImageSize/iMax(iMax(m.Width(), m.Height()), 1), 1)
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if err = png.Encode(f, RenderMap(m, paths, cellSize)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteDiff writes a compact view of the cells of the Map that are not
+// wrapped. It shows the part of the Map around those cells, with "X" for an
+// unwrapped cell, "." for a wrapped one, "#" for an obstacle and a space
+// outside the Map. If that part is too wide, it lists the runs of unwrapped
+// cells in each row instead.
+func WriteDiff(w io.Writer, m Map) {
+	minX, minY, maxX, maxY := -1, -1, -1, -1
+	for y := 0; y < m.Height(); y++ {
+		if m.CountRow(y, EmptyCell) == 0 {
+			continue
+		}
+		for x := 0; x < m.Width(); x++ {
+			if m.At(Point{x, y}) != EmptyCell {
+				continue
+			}
+			if minX < 0 {
+				minX, minY, maxX, maxY = x, y, x, y
+			}
+			minX, maxX = iMin(minX, x), iMax(maxX, x)
+			minY, maxY = iMin(minY, y), iMax(maxY, y)
+		}
+	}
+	n := m.countCells(EmptyCell)
+	fmt.Fprintf(w, "Unwrapped cells: %d\n", n)
+	if n == 0 {
+		return
+	}
+	if maxX-minX+1 > maxDiffWidth {
+		for y := maxY; y >= minY; y-- {
+			if m.CountRow(y, EmptyCell) == 0 {
+				continue
+			}
+			var runs []string
+			for x := minX; x <= maxX; x++ {
+				if m.At(Point{x, y}) != EmptyCell {
+					continue
+				}
+				x0 := x
+				for x < maxX && m.At(Point{x + 1, y}) == EmptyCell {
+					x++
+				}
+				if x == x0 {
+					runs = append(runs, fmt.Sprintf("%d", x0))
+				} else {
+					runs = append(runs, fmt.Sprintf("%d-%d", x0, x))
+				}
+			}
+			fmt.Fprintf(w, "%5d: %s\n", y, strings.Join(runs, ","))
+		}
+		return
+	}
+	// Show a margin of one cell around the unwrapped cells.
+	minX, minY = iMax(minX-1, 0), iMax(minY-1, 0)
+	maxY = iMin(maxY+1, m.Height()-1)
+	maxX = iMin(maxX+1, m.Width()-1)
+	fmt.Fprintf(w, "Cells (%d,%d) to (%d,%d):\n", minX, minY, maxX, maxY)
+	for y := maxY; y >= minY; y-- {
+		var sb strings.Builder
+		for x := minX; x <= maxX; x++ {
+			switch m.At(Point{x, y}) {
+			case EmptyCell:
+				sb.WriteByte('X')
+			case WrappedCell:
+				sb.WriteByte('.')
+			case ObstacleCell:
+				sb.WriteByte('#')
+			default:
+				sb.WriteByte(' ')
+			}
+		}
+		fmt.Fprintf(w, "%5d: %s\n", y, sb.String())
+	}
+}
@@ -3,14 +3,25 @@ package wwabr
 import (
 	"fmt"
 	"math"
+	"math/bits"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 type MapCell int
-type Map [][]MapCell
 
+// Map is a grid of cells with its origin at the lower-left corner. The cells
+// are packed into words with two bits each. Each row starts at a new word so
+// that the cells of a row can be scanned a word at a time.
+type Map struct {
+	width, height int
+	rowWords      int
+	words         []uint64
+}
+
+// The cells that can be passed through have the low bit set.
 const (
 	InvalidCell MapCell = iota
 	EmptyCell
@@ -18,17 +29,14 @@ const (
 	WrappedCell
 )
 
-type lineDir int
-
-const (
-	leftToRight lineDir = iota
-	rightToLeft
-	bottomToTop
-	topToBottom
-)
-
 const (
 	pointRegEx = "\\( *[0-9]+ *, *[0-9]+ *\\)"
+
+	cellBits     = 2
+	cellMask     = 1<<cellBits - 1
+	cellsPerWord = 64 / cellBits
+	// The low bit of each cell in a word.
+	lowCellBits = 0x5555555555555555
 )
 
 type Point struct {
@@ -40,15 +48,93 @@ type rlPolygon struct {
 	minX, minY, maxX, maxY int
 }
 
+// NewMap returns a Map of the given size with all its cells invalid.
+func NewMap(width, height int) Map {
+	rw := (width + cellsPerWord - 1) / cellsPerWord
+	return Map{width, height, rw, make([]uint64, rw*height)}
+}
+
+func (m Map) Width() int {
+	return m.width
+}
+
+func (m Map) Height() int {
+	return m.height
+}
+
+// At returns the cell of the Map at p, which must be within it.
+func (m Map) At(p Point) MapCell {
+	w := m.words[p.Y*m.rowWords+p.X/cellsPerWord]
+	return MapCell(w >> (uint(p.X%cellsPerWord) * cellBits) & cellMask)
+}
+
+// Set sets the cell of the Map at p, which must be within it.
+func (m Map) Set(p Point, mc MapCell) {
+	i := p.Y*m.rowWords + p.X/cellsPerWord
+	sh := uint(p.X%cellsPerWord) * cellBits
+	m.words[i] = m.words[i]&^(cellMask<<sh) | uint64(mc)<<sh
+}
+
+// matchCells returns a word with the low bits set of the cells of the given
+// word that have the value mc.
+func matchCells(w uint64, mc MapCell) uint64 {
+	d := w ^ lowCellBits*uint64(mc)
+	return ^(d | d>>1) & lowCellBits
+}
+
+// runMask returns the mask of the bits of the cells from x0 up to x1 that are
+// in the i-th word of a row.
+func runMask(i, x0, x1 int) uint64 {
+	lo := iMax(x0-i*cellsPerWord, 0)
+	hi := iMin(x1-i*cellsPerWord, cellsPerWord)
+	if lo >= hi {
+		return 0
+	}
+	mask := ^uint64(0)
+	if hi < cellsPerWord {
+		mask = 1<<(uint(hi)*cellBits) - 1
+	}
+	return mask &^ (1<<(uint(lo)*cellBits) - 1)
+}
+
+// row returns the words of the y-th row of the Map.
+func (m Map) row(y int) []uint64 {
+	return m.words[y*m.rowWords : (y+1)*m.rowWords]
+}
+
+// countRun returns the number of cells in the y-th row of the Map from x0 up
+// to x1 that have the value mc.
+func (m Map) countRun(y, x0, x1 int, mc MapCell) int {
+	n := 0
+	r := m.row(y)
+	for i := x0 / cellsPerWord; i < len(r) && i*cellsPerWord < x1; i++ {
+		n += bits.OnesCount64(matchCells(r[i], mc) & runMask(i, x0, x1))
+	}
+	return n
+}
+
+// CountRow returns the number of cells in the y-th row of the Map that have
+// the value mc.
+func (m Map) CountRow(y int, mc MapCell) int {
+	return m.countRun(y, 0, m.width, mc)
+}
+
+// replaceRun sets the cells in the y-th row of the Map from x0 up to x1 that
+// have the value mc0 to mc1.
+func (m Map) replaceRun(y, x0, x1 int, mc0, mc1 MapCell) {
+	r := m.row(y)
+	for i := x0 / cellsPerWord; i < len(r) && i*cellsPerWord < x1; i++ {
+		mask := matchCells(r[i], mc0) * cellMask & runMask(i, x0, x1)
+		r[i] = r[i]&^mask | lowCellBits*uint64(mc1)&mask
+	}
+}
+
 func makeMap(s string) (Map, error) {
 	rp, err := parseRlPolygon(s)
 	if err != nil {
-		return nil, err
-	}
-	m := make([][]MapCell, rp.maxY)
-	for i := 0; i < rp.maxY; i++ {
-		m[i] = make([]MapCell, rp.maxX)
+		return Map{}, err
 	}
+	m := NewMap(rp.maxX, rp.maxY)
 	fillRlPolygon(m, rp, InvalidCell, EmptyCell)
 	return m, nil
 }
@@ -71,13 +157,13 @@ func populateObstacles(m Map, s string) error {
 func printMap(m Map) {
 	// ANSI escape-sequences via:
 	// http://www.lihaoyi.com/post/BuildyourownCommandLinewithANSIescapecodes.html
-	fmt.Printf("Map-Size: %dx%d\n", len(m[0]), len(m))
-	if len(m) > 75 || len(m[0]) > 75 {
+	fmt.Printf("Map-Size: %dx%d\n", m.width, m.height)
+	if m.height > 75 || m.width > 75 {
 		return
 	}
-	for y := len(m) - 1; y >= 0; y-- {
-		for x := 0; x < len(m[y]); x++ {
-			switch m[y][x] {
+	for y := m.height - 1; y >= 0; y-- {
+		for x := 0; x < m.width; x++ {
+			switch m.At(Point{x, y}) {
 			case EmptyCell:
 				fmt.Print("\u001b[47m \u001b[0m") // BackgroundWhite
 			case ObstacleCell:
@@ -92,177 +178,27 @@ func printMap(m Map) {
 	}
 }
 
+// fillRlPolygon sets the cells inside the polygon that have the value mc0 to
+// mc1. A cell is inside the polygon if a ray from its centre towards the left
+// crosses an odd number of the vertical edges of the polygon.
 func fillRlPolygon(m Map, rp rlPolygon, mc0, mc1 MapCell) {
-	for i := 1; i <= len(rp.points); i++ {
-		drawContour(m, rp.points[i-1], rp.points[i%len(rp.points)], mc1)
-	}
-	if true {
-		fmt.Println("Contoured")
-		printMap(m)
-	} // DEBUG
-	sps := pickSeedPoints(m, rp, mc0, mc1)
-	// DEBUG
-	if true {
-		for _, s := range sps {
-			m[s.Y][s.X] = WrappedCell
-		}
-		fmt.Printf("Seeded %s\n", sps)
-		printMap(m)
-		for _, s := range sps {
-			m[s.Y][s.X] = mc0
-		}
-	}
-	for _, s := range sps {
-		floodFill(m, s, mc0, mc1)
-		if true {
-			fmt.Printf("Seed %s\n", s)
-			printMap(m)
-		} // DEBUG
-	}
-	return
-}
-
-func floodFill(m Map, sp Point, mc0, mc1 MapCell) {
-	// A transcription of the flood-fill algorithm from:
-	//   https://en.wikipedia.org/wiki/Flood_fill#Alternative_implementations
-	if mc0 == mc1 || m[sp.Y][sp.X] != mc0 {
-		return
-	}
-	m[sp.Y][sp.X] = mc1
-	q := make([]Point, 1, 1024)
-	q[0] = sp
-	mLenX, mLenY := len(m[0]), len(m)
-	var n Point
-	for len(q) > 0 {
-		n, q = q[0], q[1:] // Pop
-		x, y := n.X-1, n.Y // West
-		if x >= 0 && y >= 0 && x < mLenX && y < mLenY && m[y][x] == mc0 {
-			m[y][x] = mc1
-			q = append(q, Point{x, y})
-		}
-		x, y = n.X+1, n.Y // East
-		if x >= 0 && y >= 0 && x < mLenX && y < mLenY && m[y][x] == mc0 {
-			m[y][x] = mc1
-			q = append(q, Point{x, y})
-		}
-		x, y = n.X, n.Y+1 // North
-		if x >= 0 && y >= 0 && x < mLenX && y < mLenY && m[y][x] == mc0 {
-			m[y][x] = mc1
-			q = append(q, Point{x, y})
-		}
-		x, y = n.X, n.Y-1 // South
-		if x >= 0 && y >= 0 && x < mLenX && y < mLenY && m[y][x] == mc0 {
-			m[y][x] = mc1
-			q = append(q, Point{x, y})
-		}
-	}
-}
-
-func pickSeedPoints(m Map, rp rlPolygon, mc0, mc1 MapCell) []Point {
 	n := len(rp.points)
-	p := make([]Point, 0, n)
-	if n < 4 || rp.maxX-rp.minX < 3 || rp.maxY-rp.minY < 3 {
-		return p
-	}
-	for i := 2; i <= n; i++ {
-		p0, p1, p2 := rp.points[i-2], rp.points[i-1], rp.points[i%n]
-		if segLen(p0, p1) < 2 || segLen(p1, p2) < 2 || !isLeftBend(p0, p1, p2) {
-			continue
-		}
-		xOff, yOff := getLeftOff(p0, p1, 1)
-		x, y := p1.X + xOff, p1.Y + yOff
-		switch ld := getLineDir(p0, p1); ld {
-		case leftToRight:
-			x -= 2
-		case rightToLeft:
-			x += 1
-		case bottomToTop:
-			y -= 2
-		case topToBottom:
-			y += 1
-		}
-		if isValidSeedPoint(m, rp, mc0, mc1, x, y) {
-			p = append(p, Point{x, y})
-		}
-	}
-	return p
-}
-
-func segLen(p0, p1 Point) int {
-	if p0.X == p1.X {
-		return iAbs(p0.Y - p1.Y)
-	}
-	return iAbs(p0.X - p1.X)
-}
-
-func isLeftBend(p0, p1, p2 Point) bool {
-	switch ld01, ld12 := getLineDir(p0, p1), getLineDir(p1, p2); ld01 {
-	case leftToRight:
-		return ld12 == bottomToTop
-	case rightToLeft:
-		return ld12 == topToBottom
-	case bottomToTop:
-		return ld12 == rightToLeft
-	default:
-		return ld12 == leftToRight
-	}
-}
-
-func isValidSeedPoint(m Map, rp rlPolygon, mc0, mc1 MapCell, x, y int) bool {
-	if x < 0 || x > rp.maxX || y < 0 || y > rp.maxY {
-		return false
-	}
-	if m[y][x] != mc0 {
-		return false
-	}
-	return true
-}
-
-func drawContour(m Map, p0, p1 Point, mc MapCell) {
-	ld := getLineDir(p0, p1)
-	xOff, yOff := getLeftOff(p0, p1, 0)
-	if ld == bottomToTop || ld == topToBottom {
-		x := p0.X + xOff
-		minY, maxY := iMin(p0.Y, p1.Y), iMax(p0.Y, p1.Y)
-		for y := minY; y < maxY; y++ {
-			m[y+yOff][x] = mc
+	xs := make([]int, 0, n)
+	for y := iMax(rp.minY, 0); y < iMin(rp.maxY, m.height); y++ {
+		xs = xs[:0]
+		for i := 0; i < n; i++ {
+			p0, p1 := rp.points[i], rp.points[(i+1)%n]
+			if p0.X == p1.X && y >= iMin(p0.Y, p1.Y) && y < iMax(p0.Y, p1.Y) {
+				xs = append(xs, p0.X)
+			}
 		}
-	} else if ld == leftToRight || ld == rightToLeft {
-		minX, maxX := iMin(p0.X, p1.X), iMax(p0.X, p1.X)
-		y := p0.Y + yOff
-		for x := minX; x < maxX; x++ {
-			m[y][x+xOff] = mc
+		sort.Ints(xs)
+		for i := 1; i < len(xs); i += 2 {
+			m.replaceRun(y, iMax(xs[i-1], 0), iMin(xs[i], m.width), mc0, mc1)
 		}
 	}
 }
 
-func getLeftOff(p0, p1 Point, delta int) (xOff, yOff int) {
-	switch ld := getLineDir(p0, p1); ld {
-	case leftToRight:
-		yOff = delta
-	case rightToLeft:
-		yOff = -1 - delta
-	case bottomToTop:
-		xOff = -1 - delta
-	case topToBottom:
-		xOff = delta
-	}
-	return
-}
-
-func getLineDir(p0, p1 Point) lineDir {
-	switch {
-	case p0.X == p1.X && p0.Y < p1.Y:
-		return bottomToTop
-	case p0.X == p1.X && p0.Y > p1.Y:
-		return topToBottom
-	case p0.Y == p1.Y && p0.X < p1.X:
-		return leftToRight
-	default:
-		return rightToLeft
-	}
-}
-
 func parseRlPolygon(s string) (rlPolygon, error) {
 	var rp rlPolygon
 	n := regexp.MustCompile(pointRegEx).FindAllString(s, -1)
@@ -322,3 +258,53 @@ func parsePoint(s string) (Point, error) {
 func (p Point) String() string {
 	return fmt.Sprintf("(%d,%d)", p.X, p.Y)
 }
+
+// IsVisible checks whether the centre of the cell at "to" can be seen from the
+// centre of the cell at "from", i.e. the segment between them does not pass
+// through the interior of a cell that is not passable. Touching the corners
+// of such cells is allowed.
+func (m Map) IsVisible(from, to Point) bool {
+	minX, maxX := iMin(from.X, to.X), iMax(from.X, to.X)
+	minY, maxY := iMin(from.Y, to.Y), iMax(from.Y, to.Y)
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			p := Point{x, y}
+			if !m.isPassable(p) && crossesCell(from, to, p) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// crossesCell checks whether the segment between the centres of the cells at
+// "from" and "to" passes through the interior of the cell at c.
+func crossesCell(from, to, c Point) bool {
+	// Clip the segment against the open square of the cell, using
+	// coordinates doubled so that the centres of the cells are integers.
+	tMin, tMax := 0.0, 1.0
+	clip := func(a, b, lo int) bool {
+		a, b = 2*a+1, 2*b+1
+		lo, hi := 2*lo, 2*lo+2
+		d := b - a
+		if d == 0 {
+			return a > lo && a < hi
+		}
+		t0, t1 := float64(lo-a)/float64(d), float64(hi-a)/float64(d)
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tMin, tMax = math.Max(tMin, t0), math.Min(tMax, t1)
+		return tMin < tMax
+	}
+	return clip(from.X, to.X, c.X) && clip(from.Y, to.Y, c.Y)
+}
+
+// countCells returns the number of cells in the Map with the given value.
+func (m Map) countCells(mc MapCell) int {
+	n := 0
+	for y := 0; y < m.height; y++ {
+		n += m.CountRow(y, mc)
+	}
+	return n
+}
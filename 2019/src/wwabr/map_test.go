@@ -0,0 +1,136 @@
+package wwabr
+
+import (
+	"reflect"
+	"testing"
+)
+
+// mapRows returns the rows of the Map from the top, with "." for an empty
+// cell, "#" for an obstacle and " " for a cell outside the mine.
+func mapRows(m Map) []string {
+	var rows []string
+	for y := m.Height() - 1; y >= 0; y-- {
+		r := make([]byte, m.Width())
+		for x := range r {
+			switch m.At(Point{x, y}) {
+			case EmptyCell:
+				r[x] = '.'
+			case ObstacleCell:
+				r[x] = '#'
+			default:
+				r[x] = ' '
+			}
+		}
+		rows = append(rows, string(r))
+	}
+	return rows
+}
+
+func TestFillRlPolygon(t *testing.T) {
+	tests := []struct {
+		mine, obstacles string
+		want            []string
+	}{
+		{"(0,0),(3,0),(3,2),(0,2)", "", []string{
+			"...",
+			"...",
+		}},
+		// Clockwise.
+		{"(0,0),(0,3),(2,3),(2,1),(4,1),(4,0)", "", []string{
+			"..  ",
+			"..  ",
+			"....",
+		}},
+		// Two runs of cells in some rows.
+		{"(0,0),(5,0),(5,3),(3,3),(3,1),(2,1),(2,3),(0,3)", "", []string{
+			".. ..",
+			".. ..",
+			".....",
+		}},
+		// An obstacle inside the mine and one on its border.
+		{"(0,0),(5,0),(5,4),(0,4)", "(1,1),(2,1),(2,3),(1,3);" +
+			"(3,0),(5,0),(5,1),(3,1)", []string{
+			".....",
+			".#...",
+			".#...",
+			"...##",
+		}},
+		// An obstacle sticking out of a non-rectangular mine.
+		{"(0,0),(3,0),(3,2),(1,2),(1,3),(0,3)", "(2,1),(4,1),(4,2),(2,2)",
+			[]string{
+				".  ",
+				"..#",
+				"...",
+			}},
+	}
+	for _, tc := range tests {
+		m, err := makeMap(tc.mine)
+		if err != nil {
+			t.Fatalf("Error making Map for %q: %v", tc.mine, err)
+		}
+		if err = populateObstacles(m, tc.obstacles); err != nil {
+			t.Fatalf("Error adding obstacles %q: %v", tc.obstacles, err)
+		}
+		if got := mapRows(m); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("For %q and %q, wanted %q, got %q.", tc.mine,
+				tc.obstacles, tc.want, got)
+		}
+	}
+}
+
+func TestMapCells(t *testing.T) {
+	// Rows that fill a word exactly, spill over into another, or fit in one.
+	for _, width := range []int{1, 31, 32, 33, 64, 65, 100} {
+		m := NewMap(width, 3)
+		want := make(map[Point]MapCell)
+		for y := 0; y < 3; y++ {
+			for x := 0; x < width; x++ {
+				p := Point{x, y}
+				want[p] = MapCell((x*7 + y*3) % 4)
+				m.Set(p, want[p])
+			}
+		}
+		counts := make([]int, 4)
+		for p, mc := range want {
+			if got := m.At(p); got != mc {
+				t.Errorf("For width %d at %v, wanted %d, got %d.", width, p,
+					mc, got)
+			}
+			counts[mc]++
+		}
+		for mc, n := range counts {
+			if got := m.countCells(MapCell(mc)); got != n {
+				t.Errorf("For width %d, wanted %d cells with %d, got %d.",
+					width, n, mc, got)
+			}
+		}
+	}
+}
+
+func TestReplaceRun(t *testing.T) {
+	m := NewMap(100, 2)
+	m.replaceRun(0, 0, 100, InvalidCell, EmptyCell)
+	m.Set(Point{40, 0}, ObstacleCell)
+	m.replaceRun(0, 30, 70, EmptyCell, WrappedCell)
+	tests := []struct {
+		x0, x1 int
+		mc     MapCell
+		want   int
+	}{
+		{0, 100, EmptyCell, 60},
+		{0, 100, WrappedCell, 39},
+		{0, 100, ObstacleCell, 1},
+		{29, 31, EmptyCell, 1},
+		{69, 71, WrappedCell, 1},
+		{32, 64, WrappedCell, 31},
+	}
+	for _, tc := range tests {
+		if got := m.countRun(0, tc.x0, tc.x1, tc.mc); got != tc.want {
+			t.Errorf("For %d to %d with %d, wanted %d, got %d.", tc.x0, tc.x1,
+				tc.mc, tc.want, got)
+		}
+	}
+	if got := m.CountRow(1, InvalidCell); got != 100 {
+		t.Errorf("Wanted an invalid row, got %d invalid cells.", got)
+	}
+}
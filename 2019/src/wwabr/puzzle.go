@@ -0,0 +1,461 @@
+package wwabr
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The boosters of a puzzle, in the order of their counts in its description.
+var puzzleBoosters = []BoosterCode{ExtensionBooster, FastWheelsBooster,
+	DrillBooster, TeleportBooster, CloningBooster, MysteriousPoint}
+
+// The neighbours of a cell, in counter-clockwise order.
+var ringDeltas = []Point{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1},
+	{0, -1}, {1, -1}}
+
+// Puzzle is a block-puzzle: the constraints on a task to be generated.
+type Puzzle struct {
+	Block, Epoch int
+	// The task must fit in a square of this size.
+	Size                     int
+	MinVertices, MaxVertices int
+	// The exact number of boosters of each kind.
+	Boosters map[BoosterCode]int
+	// The squares that must be inside and outside the map, respectively.
+	Included, Excluded []Point
+}
+
+// ParsePuzzle parses the description of a puzzle.
+func ParsePuzzle(s string) (*Puzzle, error) {
+	t := strings.Split(strings.TrimSpace(s), "#")
+	if len(t) != 3 {
+		return nil, fmt.Errorf("Got %d puzzle components instead of 3.",
+			len(t))
+	}
+	ns := strings.Split(t[0], ",")
+	if len(ns) != 5+len(puzzleBoosters) {
+		return nil, fmt.Errorf("Got %d puzzle parameters instead of %d.",
+			len(ns), 5+len(puzzleBoosters))
+	}
+	vs := make([]int, len(ns))
+	for i, n := range ns {
+		v, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("Invalid puzzle parameter \"%s\".", n)
+		}
+		vs[i] = v
+	}
+	pz := &Puzzle{
+		Block:       vs[0],
+		Epoch:       vs[1],
+		Size:        vs[2],
+		MinVertices: vs[3],
+		MaxVertices: vs[4],
+		Boosters:    make(map[BoosterCode]int),
+	}
+	for i, c := range puzzleBoosters {
+		pz.Boosters[c] = vs[5+i]
+	}
+	var err error
+	if pz.Included, err = parseSquares(t[1]); err != nil {
+		return nil, err
+	}
+	if pz.Excluded, err = parseSquares(t[2]); err != nil {
+		return nil, err
+	}
+	return pz, nil
+}
+
+func parseSquares(s string) ([]Point, error) {
+	var ps []Point
+	for _, v := range regexp.MustCompile(pointRegEx).FindAllString(s, -1) {
+		p, err := parsePoint(v)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+// NewPuzzleFromFile reads the description of a puzzle from a ".cond" file.
+func NewPuzzleFromFile(p string) (*Puzzle, error) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePuzzle(string(b))
+}
+
+// minArea returns the least area of the map of a task for the puzzle.
+func (pz *Puzzle) minArea() int {
+	return int(math.Ceil(0.2 * float64(pz.Size*pz.Size)))
+}
+
+// minExtent returns the least width or height of the map of a task for the
+// puzzle.
+func (pz *Puzzle) minExtent() int {
+	return pz.Size - pz.Size/10
+}
+
+// isInside checks whether the cell at p is inside the map being generated.
+// Cells beyond the edges of the Map are outside it.
+func isInside(m Map, p Point) bool {
+	return m.contains(p) && m.At(p) == EmptyCell
+}
+
+// isCheckered checks whether the 2x2 block of cells with its lower-left cell
+// at p has only the cells on one of its diagonals inside the map. Such a
+// block makes the map touch itself at a corner.
+func isCheckered(m Map, p Point) bool {
+	a, b := isInside(m, p), isInside(m, Point{p.X + 1, p.Y})
+	c := isInside(m, Point{p.X, p.Y + 1})
+	d := isInside(m, Point{p.X + 1, p.Y + 1})
+	return a == d && b == c && a != b
+}
+
+// isSimple checks whether the cell at p can be removed from the map being
+// generated without splitting it, making a hole in it or making it touch
+// itself at a corner.
+func isSimple(m Map, p Point) bool {
+	if !isInside(m, p) {
+		return false
+	}
+	transitions := 0
+	for i, d := range ringDeltas {
+		e := ringDeltas[(i+1)%len(ringDeltas)]
+		if isInside(m, Point{p.X + d.X, p.Y + d.Y}) &&
+			!isInside(m, Point{p.X + e.X, p.Y + e.Y}) {
+			transitions++
+		}
+	}
+	if transitions != 1 {
+		return false
+	}
+	m.Set(p, InvalidCell)
+	defer m.Set(p, EmptyCell)
+	for _, d := range []Point{{-1, -1}, {0, -1}, {-1, 0}, {0, 0}} {
+		if isCheckered(m, Point{p.X + d.X, p.Y + d.Y}) {
+			return false
+		}
+	}
+	return true
+}
+
+// isInterior checks whether the cell at p and all of its neighbours are
+// inside the map being generated.
+func isInterior(m Map, p Point) bool {
+	if !isInside(m, p) {
+		return false
+	}
+	for _, d := range ringDeltas {
+		if !isInside(m, Point{p.X + d.X, p.Y + d.Y}) {
+			return false
+		}
+	}
+	return true
+}
+
+// exclude removes the cell at p from the map being generated, along with a
+// corridor of cells connecting it to the outside of the map. The corridor
+// avoids the included cells and keeps away from other corridors.
+func exclude(m Map, p Point, included map[Point]bool) error {
+	prev := map[Point]Point{p: p}
+	q := []Point{p}
+	var c Point
+	found := false
+	for len(q) > 0 && !found {
+		c, q = q[0], q[1:] // Pop
+		if isSimple(m, c) {
+			found = true
+			break
+		}
+		for _, a := range moveActions {
+			n := Point{c.X + a.delta.X, c.Y + a.delta.Y}
+			if _, seen := prev[n]; seen || included[n] {
+				continue
+			}
+			if isSimple(m, n) || isInterior(m, n) {
+				prev[n] = c
+				q = append(q, n)
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("Cannot exclude square %v.", p)
+	}
+	for {
+		if !isSimple(m, c) {
+			return fmt.Errorf("Cannot exclude square %v via %v.", p, c)
+		}
+		m.Set(c, InvalidCell)
+		if c == p {
+			return nil
+		}
+		c = prev[c]
+	}
+}
+
+// cornerVertices returns the number of vertices of the map being generated at
+// the corners of the cell at p.
+func cornerVertices(m Map, p Point) int {
+	n := 0
+	for _, d := range []Point{{-1, -1}, {0, -1}, {-1, 0}, {0, 0}} {
+		k := 0
+		for _, e := range []Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+			if isInside(m, Point{p.X + d.X + e.X, p.Y + d.Y + e.Y}) {
+				k++
+			}
+		}
+		if k == 1 || k == 3 {
+			n++
+		}
+	}
+	return n
+}
+
+// countVertices returns the number of vertices of the map being generated.
+func countVertices(m Map) int {
+	n := 0
+	for y := -1; y < m.Height(); y++ {
+		for x := -1; x < m.Width(); x++ {
+			k := 0
+			for _, e := range []Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				if isInside(m, Point{x + e.X, y + e.Y}) {
+					k++
+				}
+			}
+			if k == 1 || k == 3 {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// addVertices notches the edges of the map being generated until it has at
+// least the minimum number of vertices of the puzzle.
+func (pz *Puzzle) addVertices(m Map, included map[Point]bool) {
+	v, area := countVertices(m), m.countCells(EmptyCell)
+	for progress := true; progress && v < pz.MinVertices; {
+		progress = false
+		for y := 0; y < m.Height(); y++ {
+			for x := 0; x < m.Width(); x++ {
+				p := Point{x, y}
+				if v >= pz.MinVertices || area <= pz.minArea() ||
+					included[p] || !isSimple(m, p) {
+					continue
+				}
+				before := cornerVertices(m, p)
+				m.Set(p, InvalidCell)
+				dv := cornerVertices(m, p) - before
+				if dv <= 0 || v+dv > pz.MaxVertices {
+					m.Set(p, EmptyCell)
+					continue
+				}
+				v, area, progress = v+dv, area-1, true
+			}
+		}
+	}
+}
+
+// traceContour returns the vertices of the map being generated, in
+// counter-clockwise order so that the map is on the left of each edge.
+func traceContour(m Map) ([]Point, error) {
+	// The edges of the cells on the edge of the map, keyed by their start.
+	next := make(map[Point]Point)
+	for y := 0; y < m.Height(); y++ {
+		for x := 0; x < m.Width(); x++ {
+			p := Point{x, y}
+			if !isInside(m, p) {
+				continue
+			}
+			if !isInside(m, Point{x, y - 1}) {
+				next[p] = Point{x + 1, y}
+			}
+			if !isInside(m, Point{x + 1, y}) {
+				next[Point{x + 1, y}] = Point{x + 1, y + 1}
+			}
+			if !isInside(m, Point{x, y + 1}) {
+				next[Point{x + 1, y + 1}] = Point{x, y + 1}
+			}
+			if !isInside(m, Point{x - 1, y}) {
+				next[Point{x, y + 1}] = p
+			}
+		}
+	}
+	if len(next) == 0 {
+		return nil, fmt.Errorf("Empty map.")
+	}
+	var start Point
+	for start = range next {
+		break
+	}
+	var ps []Point
+	dir := func(a, b Point) Point {
+		return Point{iSign(b.X - a.X), iSign(b.Y - a.Y)}
+	}
+	p, n := start, 0
+	for {
+		q := next[p]
+		r := next[q]
+		if dir(p, q) != dir(q, r) {
+			ps = append(ps, q)
+		}
+		p, n = q, n+1
+		if p == start {
+			break
+		}
+	}
+	if n != len(next) {
+		return nil, fmt.Errorf("Map has %d edges outside its contour.",
+			len(next)-n)
+	}
+	return ps, nil
+}
+
+// spreadCells returns n cells of the map being generated, spread over it.
+func spreadCells(m Map, n int) []Point {
+	var cells []Point
+	for y := 0; y < m.Height(); y++ {
+		for x := 0; x < m.Width(); x++ {
+			if isInside(m, Point{x, y}) {
+				cells = append(cells, Point{x, y})
+			}
+		}
+	}
+	ps := make([]Point, n)
+	for i := range ps {
+		ps[i] = cells[(2*i+1)*len(cells)/(2*n)]
+	}
+	return ps
+}
+
+// Generate returns the description of a task that solves the puzzle. The map
+// starts as the square of the size of the puzzle, from which the excluded
+// squares are removed along with corridors connecting them to the outside.
+// Its edges are then notched to get enough vertices.
+func (pz *Puzzle) Generate() (string, error) {
+	if pz.Size <= 0 {
+		return "", fmt.Errorf("Invalid puzzle size %d.", pz.Size)
+	}
+	m := NewMap(pz.Size, pz.Size)
+	for y := 0; y < pz.Size; y++ {
+		m.replaceRun(y, 0, pz.Size, InvalidCell, EmptyCell)
+	}
+	included := make(map[Point]bool)
+	for _, p := range pz.Included {
+		if !m.contains(p) {
+			return "", fmt.Errorf("Included square %v is too far out.", p)
+		}
+		included[p] = true
+	}
+	for _, p := range pz.Excluded {
+		if included[p] {
+			return "", fmt.Errorf("Square %v is included and excluded.", p)
+		}
+		if !isInside(m, p) {
+			continue
+		}
+		if err := exclude(m, p, included); err != nil {
+			return "", err
+		}
+	}
+	pz.addVertices(m, included)
+
+	ps, err := traceContour(m)
+	if err != nil {
+		return "", err
+	}
+	vs := make([]string, len(ps))
+	for i, p := range ps {
+		vs[i] = p.String()
+	}
+	n := 1
+	for _, c := range puzzleBoosters {
+		n += pz.Boosters[c]
+	}
+	if n > m.countCells(EmptyCell) {
+		return "", fmt.Errorf("Cannot place %d boosters and a worker.", n)
+	}
+	// The worker goes in the first of the cells and the boosters in the rest.
+	cells := spreadCells(m, n)
+	var bs []string
+	for _, c := range puzzleBoosters {
+		for i := 0; i < pz.Boosters[c]; i++ {
+			bs = append(bs, Booster{c, cells[len(bs)+1]}.String())
+		}
+	}
+	desc := strings.Join(vs, ",") + "#" + cells[0].String() + "##" +
+		strings.Join(bs, ";")
+	return desc, pz.Check(desc)
+}
+
+// Check checks whether the given task description solves the puzzle.
+func (pz *Puzzle) Check(desc string) error {
+	wSys, err := parseTask(desc)
+	if err != nil {
+		return err
+	}
+	rp, err := parseRlPolygon(strings.Split(desc, "#")[0])
+	if err != nil {
+		return err
+	}
+	if len(rp.points) < pz.MinVertices || len(rp.points) > pz.MaxVertices {
+		return fmt.Errorf("Map has %d vertices instead of %d to %d.",
+			len(rp.points), pz.MinVertices, pz.MaxVertices)
+	}
+	area2 := 0
+	for i, p := range rp.points {
+		q := rp.points[(i+1)%len(rp.points)]
+		area2 += p.X*q.Y - q.X*p.Y
+	}
+	if area2 <= 0 {
+		return fmt.Errorf("Map is not on the left of its edges.")
+	}
+	if rp.minX < 0 || rp.minY < 0 || rp.maxX > pz.Size || rp.maxY > pz.Size {
+		return fmt.Errorf("Map does not fit in a square of size %d.", pz.Size)
+	}
+	if iMax(rp.maxX-rp.minX, rp.maxY-rp.minY) < pz.minExtent() {
+		return fmt.Errorf("Map is smaller than %d across.", pz.minExtent())
+	}
+	m := wSys.MineMap
+	if a := m.countCells(EmptyCell); a < pz.minArea() {
+		return fmt.Errorf("Map has an area of %d instead of at least %d.", a,
+			pz.minArea())
+	}
+	if len(strings.Split(desc, "#")[2]) > 0 {
+		return fmt.Errorf("Task has obstacles.")
+	}
+	for _, p := range pz.Included {
+		if !m.isPassable(p) {
+			return fmt.Errorf("Map does not contain included square %v.", p)
+		}
+	}
+	for _, p := range pz.Excluded {
+		if m.isPassable(p) {
+			return fmt.Errorf("Map contains excluded square %v.", p)
+		}
+	}
+	if !m.isPassable(wSys.WorkerLoc) {
+		return fmt.Errorf("Worker is at invalid location %v.", wSys.WorkerLoc)
+	}
+	counts := make(map[BoosterCode]int)
+	for _, b := range wSys.Boosters {
+		if !m.isPassable(b.Loc) {
+			return fmt.Errorf("Booster %v is outside the map.", b)
+		}
+		counts[b.Code]++
+	}
+	for _, c := range puzzleBoosters {
+		if counts[c] != pz.Boosters[c] {
+			return fmt.Errorf("Task has %d %c boosters instead of %d.",
+				counts[c], c, pz.Boosters[c])
+		}
+	}
+	return nil
+}
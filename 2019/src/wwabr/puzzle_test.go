@@ -0,0 +1,32 @@
+package wwabr
+
+import (
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		cond string
+		ok   bool
+	}{
+		{"1,1,10,4,40,1,1,0,0,0,1#(1,1),(8,8)#(5,5),(0,9)", true},
+		{"2,1,20,40,60,2,0,1,1,1,1#(0,0),(19,19),(10,10)#(9,10),(11,10)", true},
+		{"3,1,10,4,20,0,0,0,0,0,0##(5,5),(5,6),(6,5)", true},
+		// Too few vertices for the corridors to the excluded squares.
+		{"4,1,10,4,6,0,0,0,0,0,0##(5,5)", false},
+		{"5,1,10,4,20,0,0,0,0,0,0#(5,5)#(5,5)", false},
+		// Too many boosters to fit in the map.
+		{"6,1,2,4,20,1,1,1,1,1,1##", false},
+	}
+	for _, tc := range tests {
+		pz, err := ParsePuzzle(tc.cond)
+		if err != nil {
+			t.Fatalf("For %q, got error %v.", tc.cond, err)
+		}
+		desc, err := pz.Generate()
+		if got := err == nil; got != tc.ok {
+			t.Errorf("For %q, wanted success %v, got %q with error %v.",
+				tc.cond, tc.ok, desc, err)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package wwabr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The actions of a worker in a solution.
+const (
+	MoveUp            = 'W'
+	MoveDown          = 'S'
+	MoveLeft          = 'A'
+	MoveRight         = 'D'
+	DoNothing         = 'Z'
+	TurnCW            = 'E'
+	TurnCCW           = 'Q'
+	ExtendManipulator = 'B'
+	UseFastWheels     = 'F'
+	UseDrill          = 'L'
+	InstallBeacon     = 'R'
+	Teleport          = 'T'
+	Clone             = 'C'
+)
+
+const (
+	relPointRegEx = "^\\( *-?[0-9]+ *, *-?[0-9]+ *\\)"
+	// Separates the routes of the workers in a solution.
+	routeSeparator = "#"
+)
+
+var moveActions = []struct {
+	action byte
+	delta  Point
+}{
+	{MoveUp, Point{0, 1}},
+	{MoveDown, Point{0, -1}},
+	{MoveLeft, Point{-1, 0}},
+	{MoveRight, Point{1, 0}},
+}
+
+// Action is a single action of a worker. Arg is the argument of the actions
+// that need one (B and T).
+type Action struct {
+	Code byte
+	Arg  Point
+}
+
+func (a Action) String() string {
+	if a.Code == ExtendManipulator || a.Code == Teleport {
+		return fmt.Sprintf("%c%v", a.Code, a.Arg)
+	}
+	return string(a.Code)
+}
+
+// ParseActions parses the actions of a worker in a solution.
+func ParseActions(s string) ([]Action, error) {
+	var as []Action
+	re := regexp.MustCompile(relPointRegEx)
+	for i := 0; i < len(s); i++ {
+		a := Action{Code: s[i]}
+		switch a.Code {
+		case MoveUp, MoveDown, MoveLeft, MoveRight, DoNothing, TurnCW, TurnCCW,
+			UseFastWheels, UseDrill, InstallBeacon, Clone:
+		case ExtendManipulator, Teleport:
+			arg := re.FindString(s[i+1:])
+			if arg == "" {
+				return nil, fmt.Errorf("Missing argument for action %d (%c).",
+					len(as), a.Code)
+			}
+			n := strings.Split(strings.Trim(arg, "()"), ",")
+			a.Arg.X, _ = strconv.Atoi(strings.TrimSpace(n[0]))
+			a.Arg.Y, _ = strconv.Atoi(strings.TrimSpace(n[1]))
+			i += len(arg)
+		default:
+			return nil, fmt.Errorf("Invalid action '%c' at offset %d.", s[i], i)
+		}
+		as = append(as, a)
+	}
+	return as, nil
+}
+
+// ParseSolution parses a solution into the routes of the workers, in the order
+// in which they are spawned.
+func ParseSolution(s string) ([][]Action, error) {
+	var routes [][]Action
+	for i, r := range strings.Split(s, routeSeparator) {
+		as, err := ParseActions(r)
+		if err != nil {
+			return nil, fmt.Errorf("Route %d: %v", i, err)
+		}
+		routes = append(routes, as)
+	}
+	return routes, nil
+}
+
+// FormatSolution formats the routes of the workers into a solution.
+func FormatSolution(routes [][]Action) string {
+	rs := make([]string, len(routes))
+	for i, as := range routes {
+		var sb strings.Builder
+		for _, a := range as {
+			sb.WriteString(a.String())
+		}
+		rs[i] = sb.String()
+	}
+	return strings.Join(rs, routeSeparator)
+}
+
+// Simulation tracks the state of a WwabrSystem as its workers perform actions.
+// The workers act in the order in which they were spawned in each time-unit.
+type Simulation struct {
+	Map     Map
+	Workers []*Worker
+	Time    int
+	// The number of workers that can act in the current time-unit.
+	active      int
+	boosters    map[Point]BoosterCode
+	spawnPoints map[Point]bool
+	beacons     map[Point]bool
+}
+
+// NewSimulation returns a Simulation of the given system, with a copy of its
+// Map and the cells under the worker wrapped.
+func NewSimulation(wSys *WwabrSystem) (*Simulation, error) {
+	s := &Simulation{
+		Map:         wSys.MineMap.Copy(),
+		boosters:    make(map[Point]BoosterCode),
+		spawnPoints: make(map[Point]bool),
+		beacons:     make(map[Point]bool),
+	}
+	if !s.Map.isPassable(wSys.WorkerLoc) {
+		return nil, fmt.Errorf("Worker is at invalid location %v.",
+			wSys.WorkerLoc)
+	}
+	for _, b := range wSys.Boosters {
+		if b.Code == MysteriousPoint {
+			s.spawnPoints[b.Loc] = true
+		} else {
+			s.boosters[b.Loc] = b.Code
+		}
+	}
+	s.spawn(wSys.WorkerLoc)
+	// The worker collects a booster at its location before its first action.
+	s.Workers[0].settle(s.Time + 1)
+	s.active = 1
+	return s, nil
+}
+
+// spawn adds a new worker at the given location. It only acts from the next
+// time-unit onwards.
+func (s *Simulation) spawn(loc Point) {
+	w := NewWorker(loc)
+	s.Workers = append(s.Workers, w)
+	s.collect(w)
+	s.wrap(w)
+}
+
+// Paths returns the paths of the workers.
+func (s *Simulation) Paths() [][]Point {
+	ps := make([][]Point, len(s.Workers))
+	for i, w := range s.Workers {
+		ps[i] = w.Path
+	}
+	return ps
+}
+
+// wrap wraps the cells within the reach of the worker. It returns the number
+// of newly-wrapped cells.
+func (s *Simulation) wrap(w *Worker) int {
+	n := 0
+	for _, p := range w.Reach(s.Map) {
+		if s.Map.At(p) == EmptyCell {
+			s.Map.Set(p, WrappedCell)
+			n++
+		}
+	}
+	return n
+}
+
+// collect picks up the booster at the location of the worker, if any.
+func (s *Simulation) collect(w *Worker) {
+	if c, ok := s.boosters[w.Loc]; ok {
+		w.collected = append(w.collected, collection{c, s.Time})
+		delete(s.boosters, w.Loc)
+	}
+}
+
+// canEnter checks whether a worker can move into the given location, drilling
+// through obstacles if drill is true. Even a drilling worker cannot leave the
+// mine.
+func (s *Simulation) canEnter(p Point, drill bool) bool {
+	if drill && s.Map.contains(p) && s.Map.At(p) == ObstacleCell {
+		return true
+	}
+	return s.Map.isPassable(p)
+}
+
+func (s *Simulation) move(w *Worker, d Point, fast, drill bool) error {
+	for i := 0; i < 2; i++ {
+		p := Point{w.Loc.X + d.X, w.Loc.Y + d.Y}
+		if !s.canEnter(p, drill) {
+			if i == 0 {
+				return fmt.Errorf("Cannot move into %v.", p)
+			}
+			break
+		}
+		if !s.Map.isPassable(p) {
+			s.Map.Set(p, WrappedCell) // Drilled.
+		}
+		w.moveTo(p)
+		s.collect(w)
+		s.wrap(w)
+		if !fast {
+			break
+		}
+	}
+	return nil
+}
+
+// useBooster takes a booster for the i-th worker from its own inventory or,
+// failing that, from that of another worker, since boosters are shared by all
+// of them. A booster collected by the j-th worker at time N is available to
+// the workers spawned no earlier than it from time N+1, and to all of them
+// from time N+2.
+func (s *Simulation) useBooster(i int, c BoosterCode) error {
+	take := func(j int) bool {
+		o := s.Workers[j]
+		if o.Inventory[c] > 0 {
+			o.Inventory[c]--
+			return true
+		}
+		for k, b := range o.collected {
+			if b.code == c && b.time < s.Time && j <= i {
+				o.collected = append(o.collected[:k], o.collected[k+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+	if take(i) {
+		return nil
+	}
+	for j := range s.Workers {
+		if j != i && take(j) {
+			return nil
+		}
+	}
+	return fmt.Errorf("No %c booster in the inventory.", c)
+}
+
+// Act performs an action of the i-th worker in the current time-unit. An
+// illegal action leaves the Simulation as it was.
+func (s *Simulation) Act(i int, a Action) error {
+	if i < 0 || i >= s.active {
+		return fmt.Errorf("Worker %d cannot act at time %d.", i, s.Time)
+	}
+	w := s.Workers[i]
+	fastLeft, drillLeft := w.fastLeft, w.drillLeft
+	w.fastLeft = iMax(fastLeft-1, 0)
+	w.drillLeft = iMax(drillLeft-1, 0)
+	if err := s.apply(i, a, fastLeft > 0, drillLeft > 0); err != nil {
+		w.fastLeft, w.drillLeft = fastLeft, drillLeft
+		return err
+	}
+	return nil
+}
+
+// EndTurn ends the current time-unit. Workers spawned during it can act from
+// the next one.
+func (s *Simulation) EndTurn() {
+	s.active = len(s.Workers)
+	s.Time++
+	for _, w := range s.Workers {
+		w.settle(s.Time - 1)
+	}
+}
+
+func (s *Simulation) apply(i int, a Action, fast, drill bool) error {
+	w := s.Workers[i]
+	for _, m := range moveActions {
+		if a.Code == m.action {
+			return s.move(w, m.delta, fast, drill)
+		}
+	}
+	switch a.Code {
+	case DoNothing:
+	case TurnCW, TurnCCW:
+		w.Turn(a.Code == TurnCW)
+	case ExtendManipulator:
+		if err := w.canAttach(a.Arg); err != nil {
+			return err
+		}
+		if err := s.useBooster(i, ExtensionBooster); err != nil {
+			return err
+		}
+		w.Attach(a.Arg) // Cannot fail after canAttach.
+	case UseFastWheels:
+		if err := s.useBooster(i, FastWheelsBooster); err != nil {
+			return err
+		}
+		w.fastLeft = fastWheelsDuration
+	case UseDrill:
+		if err := s.useBooster(i, DrillBooster); err != nil {
+			return err
+		}
+		w.drillLeft = drillDuration
+	case InstallBeacon:
+		if s.beacons[w.Loc] || s.spawnPoints[w.Loc] {
+			return fmt.Errorf("Cannot install a beacon at %v.", w.Loc)
+		}
+		if err := s.useBooster(i, TeleportBooster); err != nil {
+			return err
+		}
+		s.beacons[w.Loc] = true
+	case Teleport:
+		if !s.beacons[a.Arg] {
+			return fmt.Errorf("No beacon at %v.", a.Arg)
+		}
+		w.moveTo(a.Arg)
+		s.collect(w)
+	case Clone:
+		if !s.spawnPoints[w.Loc] {
+			return fmt.Errorf("Cannot clone away from a spawn-point at %v.",
+				w.Loc)
+		}
+		if err := s.useBooster(i, CloningBooster); err != nil {
+			return err
+		}
+		s.spawn(w.Loc)
+	default:
+		return fmt.Errorf("Unsupported action %v.", a)
+	}
+	s.wrap(w)
+	return nil
+}
@@ -0,0 +1,240 @@
+package wwabr
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReplayClones(t *testing.T) {
+	// A cloning booster at (1,0), a spawn-point at (2,0) and an extension
+	// booster at (3,0), as per Appendix A of the specification on the parallel
+	// collection and use of boosters.
+	wSys := &WwabrSystem{
+		MineMap:   newTestMap(6, 3),
+		WorkerLoc: Point{0, 0},
+		Boosters: []Booster{{CloningBooster, Point{1, 0}},
+			{MysteriousPoint, Point{2, 0}}, {ExtensionBooster, Point{3, 0}}},
+	}
+	tests := []struct {
+		solution string
+		workers  int
+		ok       bool
+	}{
+		{"DDC", 2, true},
+		{"DC", 1, false},
+		{"DDCC", 2, false},
+		{"DDC#Z#Z", 2, false},
+		// A clone starts acting in the time-unit after it was spawned.
+		{"DDC#D", 2, true},
+		// An extension booster collected by a worker in time-unit 3 ...
+		{"DDCD#B(1,2)", 2, false},
+		{"DDCDB(1,2)", 2, true},
+		{"DDCZ#DB(1,2)", 2, true},
+		// ... is available to earlier workers only in time-unit 5.
+		{"DDCZB(1,2)#D", 2, false},
+		{"DDCZZB(1,2)#D", 2, true},
+		{"DDCDZ#ZB(1,2)", 2, true},
+	}
+	for _, tc := range tests {
+		sim, err := Replay(wSys, tc.solution)
+		if got := err == nil; got != tc.ok {
+			t.Errorf("For %q, wanted success %v, got error %v.", tc.solution,
+				tc.ok, err)
+		}
+		if sim != nil && len(sim.Workers) != tc.workers {
+			t.Errorf("For %q, wanted %d workers, got %d.", tc.solution,
+				tc.workers, len(sim.Workers))
+		}
+	}
+}
+
+func TestReplayDrill(t *testing.T) {
+	// An obstacle at (1,0) and a cell outside the mine at (1,1), with a drill
+	// booster at (0,1).
+	m := newTestMap(4, 2, Point{1, 0})
+	m.Set(Point{1, 1}, InvalidCell)
+	wSys := &WwabrSystem{
+		MineMap:   m,
+		WorkerLoc: Point{0, 0},
+		Boosters:  []Booster{{DrillBooster, Point{0, 1}}},
+	}
+	tests := []struct {
+		solution string
+		ok       bool
+	}{
+		{"D", false},
+		{"WLSD", true},
+		{"WLSDD", true},
+		// Drilling does not allow leaving the mine.
+		{"WLD", false},
+		{"WLSDW", false},
+	}
+	for _, tc := range tests {
+		if _, err := Replay(wSys, tc.solution); (err == nil) != tc.ok {
+			t.Errorf("For %q, wanted success %v, got error %v.", tc.solution,
+				tc.ok, err)
+		}
+	}
+}
+
+func TestParseBoosters(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []Booster
+		ok   bool
+	}{
+		{"", nil, true},
+		{"B(1,2)", []Booster{{ExtensionBooster, Point{1, 2}}}, true},
+		{"B(0,1);F(2,3);L(4,5);X(6,7);R(8,9);C(10,11)", []Booster{
+			{ExtensionBooster, Point{0, 1}}, {FastWheelsBooster, Point{2, 3}},
+			{DrillBooster, Point{4, 5}}, {MysteriousPoint, Point{6, 7}},
+			{TeleportBooster, Point{8, 9}}, {CloningBooster, Point{10, 11}}},
+			true},
+		{"T(1,2)", nil, false},
+		{"B(1,2);", nil, false},
+		{"B1,2", nil, false},
+	}
+	for _, tc := range tests {
+		got, err := parseBoosters(tc.s)
+		if (err == nil) != tc.ok {
+			t.Errorf("For %q, wanted success %v, got error %v.", tc.s, tc.ok,
+				err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("For %q, wanted %v, got %v.", tc.s, tc.want, got)
+		}
+	}
+}
+
+func TestReplayBoosters(t *testing.T) {
+	// The worker starts at (0,0) with a booster of the given kind at (1,0), a
+	// spawn-point at (3,0) and an obstacle at (2,1).
+	tests := []struct {
+		code     BoosterCode
+		solution string
+		ok       bool
+		// The location and the number of boosters of the given kind in the
+		// Inventory of the worker at the end.
+		loc       Point
+		inventory int
+	}{
+		// Boosters must be collected before they can be used, and are only in
+		// the Inventory from the time-unit after that.
+		{ExtensionBooster, "D", true, Point{1, 0}, 0},
+		{ExtensionBooster, "DZ", true, Point{1, 0}, 1},
+		{ExtensionBooster, "DB(1,2)", true, Point{1, 0}, 0},
+		{ExtensionBooster, "B(1,2)", false, Point{0, 0}, 0},
+		{ExtensionBooster, "DB(3,1)", false, Point{1, 0}, 0},
+		{ExtensionBooster, "DB(1,2)B(1,3)", false, Point{1, 0}, 0},
+		{FastWheelsBooster, "DZ", true, Point{1, 0}, 1},
+		{FastWheelsBooster, "F", false, Point{0, 0}, 0},
+		{FastWheelsBooster, "DFD", true, Point{3, 0}, 0},
+		// A fast move stops short at a wall or an obstacle ...
+		{FastWheelsBooster, "DDDDFD", true, Point{5, 0}, 0},
+		{FastWheelsBooster, "DAWFD", true, Point{1, 1}, 0},
+		// ... but fails if it cannot move at all.
+		{FastWheelsBooster, "DFDDD", false, Point{5, 0}, 0},
+		// The wheels last for 50 actions after the one using them.
+		{FastWheelsBooster, "DF" + strings.Repeat("Z", 49) + "D", true,
+			Point{3, 0}, 0},
+		{FastWheelsBooster, "DF" + strings.Repeat("Z", 50) + "D", true,
+			Point{2, 0}, 0},
+		{DrillBooster, "DZ", true, Point{1, 0}, 1},
+		{DrillBooster, "L", false, Point{0, 0}, 0},
+		{DrillBooster, "DWD", false, Point{1, 1}, 1},
+		{DrillBooster, "DLWD", true, Point{2, 1}, 0},
+		// The drill lasts for 30 actions after the one using it.
+		{DrillBooster, "DL" + strings.Repeat("Z", 28) + "WD", true,
+			Point{2, 1}, 0},
+		{DrillBooster, "DL" + strings.Repeat("Z", 29) + "WD", false,
+			Point{1, 1}, 0},
+		// Spawn-points are not collected.
+		{MysteriousPoint, "DZ", true, Point{1, 0}, 0},
+		{MysteriousPoint, "DC", false, Point{1, 0}, 0},
+		{TeleportBooster, "DZ", true, Point{1, 0}, 1},
+		{TeleportBooster, "R", false, Point{0, 0}, 0},
+		{TeleportBooster, "DR", true, Point{1, 0}, 0},
+		{TeleportBooster, "DRDDT(1,0)", true, Point{1, 0}, 0},
+		{TeleportBooster, "DDT(1,0)", false, Point{2, 0}, 1},
+		{TeleportBooster, "DRDT(2,0)", false, Point{2, 0}, 0},
+		// Beacons cannot be installed on spawn-points.
+		{TeleportBooster, "DDDR", false, Point{3, 0}, 1},
+		{CloningBooster, "DZ", true, Point{1, 0}, 1},
+		{CloningBooster, "DC", false, Point{1, 0}, 0},
+		{CloningBooster, "DDDC", true, Point{3, 0}, 0},
+	}
+	for _, tc := range tests {
+		wSys := &WwabrSystem{
+			MineMap:   newTestMap(6, 3, Point{2, 1}),
+			WorkerLoc: Point{0, 0},
+			Boosters: []Booster{{tc.code, Point{1, 0}},
+				{MysteriousPoint, Point{3, 0}}},
+		}
+		sim, err := Replay(wSys, tc.solution)
+		if (err == nil) != tc.ok {
+			t.Errorf("For %c and %q, wanted success %v, got error %v.",
+				tc.code, tc.solution, tc.ok, err)
+		}
+		if sim == nil {
+			continue
+		}
+		w := sim.Workers[0]
+		if w.Loc != tc.loc {
+			t.Errorf("For %c and %q, wanted the worker at %v, got %v.",
+				tc.code, tc.solution, tc.loc, w.Loc)
+		}
+		if got := w.Inventory[tc.code]; got != tc.inventory {
+			t.Errorf("For %c and %q, wanted %d boosters, got %d.", tc.code,
+				tc.solution, tc.inventory, got)
+		}
+	}
+}
+
+func TestActIllegal(t *testing.T) {
+	// The worker starts at (0,0) with an extension booster at (1,0) and fast
+	// wheels at (2,0). Its illegal actions change nothing.
+	tests := []struct {
+		solution string
+		illegal  int
+		loc      Point
+		arms     int
+	}{
+		// The extension booster is kept for a later extension that fits.
+		{"DB(3,1)B(1,2)", 1, Point{1, 0}, 4},
+		// The wheels are not counted down by illegal actions.
+		{"DDF" + strings.Repeat("T(5,5)", 60) + "A", 60, Point{0, 0}, 3},
+	}
+	for _, tc := range tests {
+		wSys := &WwabrSystem{
+			MineMap:   newTestMap(6, 3),
+			WorkerLoc: Point{0, 0},
+			Boosters: []Booster{{ExtensionBooster, Point{1, 0}},
+				{FastWheelsBooster, Point{2, 0}}},
+		}
+		sim, err := NewSimulation(wSys)
+		if err != nil {
+			t.Fatalf("Got error %v.", err)
+		}
+		as, err := ParseActions(tc.solution)
+		if err != nil {
+			t.Fatalf("For %q, got error %v.", tc.solution, err)
+		}
+		illegal := 0
+		for _, a := range as {
+			if sim.Act(0, a) != nil {
+				illegal++
+			}
+			sim.EndTurn()
+		}
+		w := sim.Workers[0]
+		if illegal != tc.illegal || w.Loc != tc.loc ||
+			len(w.Manipulators()) != tc.arms {
+			t.Errorf("For %q, wanted %d illegal actions, the worker at %v "+
+				"and %d manipulators, got %d, %v and %d.", tc.solution,
+				tc.illegal, tc.loc, tc.arms, illegal, w.Loc,
+				len(w.Manipulators()))
+		}
+	}
+}
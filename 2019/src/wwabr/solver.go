@@ -0,0 +1,243 @@
+package wwabr
+
+import (
+	"io/ioutil"
+)
+
+func (m Map) contains(p Point) bool {
+	return p.Y >= 0 && p.Y < m.height && p.X >= 0 && p.X < m.width
+}
+
+// isPassable checks whether a worker can be at the given location.
+func (m Map) isPassable(p Point) bool {
+	return m.contains(p) && m.At(p)&1 != 0
+}
+
+// Copy returns a copy of the Map that can be modified independently of it.
+func (m Map) Copy() Map {
+	c := m
+	c.words = append([]uint64(nil), m.words...)
+	return c
+}
+
+// shortestPath returns the moves taking a worker at the given location along
+// a shortest path to the nearest cell satisfying isTarget, found using a
+// breadth-first search, along with the location of that cell.
+func shortestPath(m Map, loc Point, isTarget func(Point) bool) ([]int, Point,
+	bool) {
+	prev := map[Point]int{loc: -1}
+	q := []Point{loc}
+	var p Point
+	for len(q) > 0 {
+		p, q = q[0], q[1:] // Pop
+		if isTarget(p) {
+			target := p
+			var path []int
+			for prev[p] >= 0 {
+				path = append(path, prev[p])
+				d := moveActions[prev[p]].delta
+				p = Point{p.X - d.X, p.Y - d.Y}
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path, target, true
+		}
+		for i, a := range moveActions {
+			n := Point{p.X + a.delta.X, p.Y + a.delta.Y}
+			if _, seen := prev[n]; seen || !m.isPassable(n) {
+				continue
+			}
+			prev[n] = i
+			q = append(q, n)
+		}
+	}
+	return nil, loc, false
+}
+
+// pathToUnwrapped returns the moves taking a worker at the given location
+// along a shortest path to the nearest unwrapped cell, along with the location
+// of that cell.
+func pathToUnwrapped(m Map, loc Point) ([]int, Point, bool) {
+	return shortestPath(m, loc, func(p Point) bool {
+		return m.At(p) == EmptyCell
+	})
+}
+
+// route is the state of the solver for a worker.
+type route struct {
+	actions []Action
+	// The region of the Map that the worker has to wrap.
+	region int
+	// The moves left to reach the target.
+	path   []int
+	target Point
+	done   bool
+}
+
+// walkTo moves the first worker of the Simulation to the nearest cell
+// satisfying isTarget, while the Simulation has only the one worker.
+func walkTo(sim *Simulation, r *route, isTarget func(Point) bool) (bool,
+	error) {
+	path, _, ok := shortestPath(sim.Map, sim.Workers[0].Loc, isTarget)
+	if !ok {
+		return false, nil
+	}
+	for _, i := range path {
+		if err := r.act(sim, 0, Action{Code: moveActions[i].action}); err != nil {
+			return false, err
+		}
+		sim.EndTurn()
+	}
+	return true, nil
+}
+
+func (r *route) act(sim *Simulation, i int, a Action) error {
+	if err := sim.Act(i, a); err != nil {
+		return err
+	}
+	r.actions = append(r.actions, a)
+	return nil
+}
+
+// spawnClones makes the first worker collect the reachable cloning boosters
+// and then use them at the nearest spawn-point. It returns the number of
+// workers at the end.
+func spawnClones(wSys *WwabrSystem, sim *Simulation, r *route) (int, error) {
+	hasSpawnPoint := false
+	cloners := make(map[Point]bool)
+	for _, b := range wSys.Boosters {
+		if b.Code == CloningBooster {
+			cloners[b.Loc] = true
+		} else if b.Code == MysteriousPoint {
+			hasSpawnPoint = true
+		}
+	}
+	if len(cloners) == 0 || !hasSpawnPoint {
+		return 1, nil
+	}
+	n := 0
+	for len(cloners) > 0 {
+		ok, err := walkTo(sim, r, func(p Point) bool { return cloners[p] })
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+		delete(cloners, sim.Workers[0].Loc)
+		n++
+	}
+	if n == 0 {
+		return 1, nil
+	}
+	ok, err := walkTo(sim, r, func(p Point) bool { return sim.spawnPoints[p] })
+	if err != nil || !ok {
+		return 1, err
+	}
+	for i := 0; i < n; i++ {
+		if err = r.act(sim, 0, Action{Code: Clone}); err != nil {
+			return 0, err
+		}
+		sim.EndTurn()
+	}
+	return len(sim.Workers), nil
+}
+
+// partition divides the unwrapped cells of the Map into the given number of
+// disjoint regions, as bands along its longer side with about as many cells
+// each. It returns the region of each cell.
+func partition(m Map, n int) [][]int {
+	regions := make([][]int, m.height)
+	for y := range regions {
+		regions[y] = make([]int, m.width)
+	}
+	if n <= 1 {
+		return regions
+	}
+	var cells []Point
+	if m.width >= m.height {
+		for x := 0; x < m.width; x++ {
+			for y := 0; y < m.height; y++ {
+				cells = append(cells, Point{x, y})
+			}
+		}
+	} else {
+		for y := 0; y < m.height; y++ {
+			for x := 0; x < m.width; x++ {
+				cells = append(cells, Point{x, y})
+			}
+		}
+	}
+	total := m.countCells(EmptyCell)
+	seen := 0
+	for _, p := range cells {
+		regions[p.Y][p.X] = iMin(seen*n/iMax(total, 1), n-1)
+		if m.At(p) == EmptyCell {
+			seen++
+		}
+	}
+	return regions
+}
+
+// Solve returns the routes of the workers that wrap all the reachable cells of
+// the Map. If there are cloning boosters and spawn-points, the first worker
+// collects the boosters and spawns clones. The unwrapped cells are then split
+// into disjoint regions, one for each worker, and each worker repeatedly moves
+// towards the nearest unwrapped cell of its region until that cell gets
+// wrapped.
+func Solve(wSys *WwabrSystem) (string, error) {
+	sim, err := NewSimulation(wSys)
+	if err != nil {
+		return "", err
+	}
+	routes := []*route{{}}
+	n, err := spawnClones(wSys, sim, routes[0])
+	if err != nil {
+		return "", err
+	}
+	for i := 1; i < n; i++ {
+		routes = append(routes, &route{region: i})
+	}
+	regions := partition(sim.Map, n)
+	for {
+		acted := false
+		for i, r := range routes {
+			if r.done {
+				continue
+			}
+			if len(r.path) == 0 || sim.Map.At(r.target) != EmptyCell {
+				var ok bool
+				r.path, r.target, ok = shortestPath(sim.Map, sim.Workers[i].Loc,
+					func(p Point) bool {
+						return sim.Map.At(p) == EmptyCell &&
+							regions[p.Y][p.X] == r.region
+					})
+				if !ok {
+					r.done = true
+					continue
+				}
+			}
+			a := Action{Code: moveActions[r.path[0]].action}
+			if err = r.act(sim, i, a); err != nil {
+				return "", err
+			}
+			r.path = r.path[1:]
+			acted = true
+		}
+		if !acted {
+			break
+		}
+		sim.EndTurn()
+	}
+	as := make([][]Action, len(routes))
+	for i, r := range routes {
+		as[i] = r.actions
+	}
+	return FormatSolution(as), nil
+}
+
+// WriteSolutionFile writes the given solution into a file.
+func WriteSolutionFile(p string, sol string) error {
+	return ioutil.WriteFile(p, []byte(sol+"\n"), 0644)
+}
@@ -15,9 +15,20 @@ const (
 type WwabrSystem struct {
 	MineMap   Map
 	WorkerLoc Point
+	Boosters  []Booster
 }
 
 func NewFromFile(p string) (*WwabrSystem, error) {
+	wSys, err := readTask(p)
+	if err != nil {
+		return nil, err
+	}
+	printMap(wSys.MineMap)
+	return wSys, nil
+}
+
+// readTask reads the description of a task from a file.
+func readTask(p string) (*WwabrSystem, error) {
 	f, err := os.Open(p)
 	if err != nil {
 		return nil, err
@@ -26,28 +37,40 @@ func NewFromFile(p string) (*WwabrSystem, error) {
 	s := bufio.NewScanner(f)
 	s.Buffer(make([]byte, maxInputLineSize), maxInputLineSize)
 
-	var wSys WwabrSystem
-	done := false
+	var wSys *WwabrSystem
 	for s.Scan() {
-		if done {
+		if wSys != nil {
 			return nil, errors.New("Extra line in problem-description.")
 		}
-		t := strings.Split(s.Text(), "#")
-		if len(t) != 4 {
-			return nil, fmt.Errorf("Got %d tuples instead of 4.", len(t))
-		}
-		if wSys.MineMap, err = makeMap(t[0]); err != nil {
-			return nil, err
-		}
-		if wSys.WorkerLoc, err = parsePoint(t[1]); err != nil {
+		if wSys, err = parseTask(s.Text()); err != nil {
 			return nil, err
 		}
-		if err = populateObstacles(wSys.MineMap, t[2]); err != nil {
-			return nil, err
-		}
-		printMap(wSys.MineMap)
-		done = true
 	}
+	if wSys == nil {
+		return nil, errors.New("Empty problem-description.")
+	}
+	return wSys, nil
+}
 
+// parseTask parses the description of a task.
+func parseTask(s string) (*WwabrSystem, error) {
+	var wSys WwabrSystem
+	var err error
+	t := strings.Split(s, "#")
+	if len(t) != 4 {
+		return nil, fmt.Errorf("Got %d tuples instead of 4.", len(t))
+	}
+	if wSys.MineMap, err = makeMap(t[0]); err != nil {
+		return nil, err
+	}
+	if wSys.WorkerLoc, err = parsePoint(t[1]); err != nil {
+		return nil, err
+	}
+	if err = populateObstacles(wSys.MineMap, t[2]); err != nil {
+		return nil, err
+	}
+	if wSys.Boosters, err = parseBoosters(t[3]); err != nil {
+		return nil, err
+	}
 	return &wSys, nil
 }
@@ -28,6 +28,16 @@ func iMax(a, b int) int {
 	return b
 }
 
+func iSign(a int) int {
+	switch {
+	case a < 0:
+		return -1
+	case a > 0:
+		return 1
+	}
+	return 0
+}
+
 // ExitWithErrorMsg prints the given error-message to stderr and then exits with
 // an error-code.
 func ExitWithErrorMsg(errMsg string) {
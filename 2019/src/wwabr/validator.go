@@ -0,0 +1,73 @@
+package wwabr
+
+import (
+	"fmt"
+)
+
+// Replay performs the routes of the workers in the given solution on a
+// Simulation of the system. The i-th route is followed by the i-th worker to
+// be spawned, and a worker that comes to the end of its route waits for the
+// others. On an illegal action, it returns the Simulation as it was before
+// that action along with the error.
+func Replay(wSys *WwabrSystem, solution string) (*Simulation, error) {
+	routes, err := ParseSolution(solution)
+	if err != nil {
+		return nil, err
+	}
+	sim, err := NewSimulation(wSys)
+	if err != nil {
+		return nil, err
+	}
+	next := make([]int, len(routes))
+	for {
+		// Workers spawned in this time-unit only act from the next one.
+		n := iMin(len(sim.Workers), len(routes))
+		acted := false
+		for i := 0; i < n; i++ {
+			if next[i] == len(routes[i]) {
+				continue
+			}
+			a := routes[i][next[i]]
+			if err = sim.Act(i, a); err != nil {
+				return sim, fmt.Errorf("Illegal action %d (%v) of worker %d: %v",
+					next[i], a, i, err)
+			}
+			next[i]++
+			acted = true
+		}
+		if !acted {
+			break
+		}
+		sim.EndTurn()
+	}
+	if len(routes) > len(sim.Workers) {
+		return sim, fmt.Errorf("Got %d routes for %d workers.", len(routes),
+			len(sim.Workers))
+	}
+	return sim, nil
+}
+
+// Validate replays the given solution on a copy of the Map of the system and
+// returns the number of time-units it takes. It fails on the first illegal
+// action, or if some cells of the Map are left unwrapped.
+func Validate(wSys *WwabrSystem, solution string) (int, error) {
+	sim, err := ValidateSim(wSys, solution)
+	if sim == nil {
+		return 0, err
+	}
+	return sim.Time, err
+}
+
+// ValidateSim is like Validate, but returns the Simulation that it replays the
+// solution on instead of the number of time-units, as Replay does.
+func ValidateSim(wSys *WwabrSystem, solution string) (*Simulation, error) {
+	sim, err := Replay(wSys, solution)
+	if err != nil {
+		return sim, err
+	}
+	if n := sim.Map.countCells(EmptyCell); n > 0 {
+		return sim, fmt.Errorf("%d cells are not wrapped after %d "+
+			"time-units.", n, sim.Time)
+	}
+	return sim, nil
+}
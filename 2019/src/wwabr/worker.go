@@ -0,0 +1,138 @@
+package wwabr
+
+import (
+	"fmt"
+)
+
+// The initial manipulators of a worker relative to its location.
+var defaultManipulators = []Point{{1, 0}, {1, 1}, {1, -1}}
+
+// Worker is a worker-wrapper along with its manipulators.
+type Worker struct {
+	Loc Point
+	// The locations of the worker after each of its moves, starting with its
+	// initial location.
+	Path []Point
+	// The locations of the manipulators relative to Loc, as attached when the
+	// worker was not turned.
+	manipulators []Point
+	// The number of quarter-turns clockwise of the worker, in [0, 3].
+	rotation int
+	// The number of time-units for which a booster remains active.
+	fastLeft  int
+	drillLeft int
+	// The boosters collected by the worker that are available to all workers,
+	// and those collected too recently to be available to all of them.
+	Inventory map[BoosterCode]int
+	collected []collection
+}
+
+// collection is a booster collected by a worker at a given time.
+type collection struct {
+	code BoosterCode
+	time int
+}
+
+// NewWorker returns a Worker at the given location with the initial
+// manipulators.
+func NewWorker(loc Point) *Worker {
+	return &Worker{
+		Loc:          loc,
+		Path:         []Point{loc},
+		manipulators: append([]Point(nil), defaultManipulators...),
+		Inventory:    make(map[BoosterCode]int),
+	}
+}
+
+// rotate returns the relative location p turned clockwise by the given number
+// of quarter-turns.
+func rotate(p Point, quarters int) Point {
+	for i := 0; i < (quarters%4+4)%4; i++ {
+		p = Point{p.Y, -p.X}
+	}
+	return p
+}
+
+// moveTo moves the worker to the given location.
+func (w *Worker) moveTo(p Point) {
+	w.Loc = p
+	w.Path = append(w.Path, p)
+}
+
+// settle adds the boosters collected before the given time to the Inventory of
+// the worker.
+func (w *Worker) settle(t int) {
+	var cs []collection
+	for _, c := range w.collected {
+		if c.time < t {
+			w.Inventory[c.code]++
+		} else {
+			cs = append(cs, c)
+		}
+	}
+	w.collected = cs
+}
+
+// Turn turns the manipulators of the worker by 90 degrees.
+func (w *Worker) Turn(clockwise bool) {
+	if clockwise {
+		w.rotation = (w.rotation + 1) % 4
+	} else {
+		w.rotation = (w.rotation + 3) % 4
+	}
+}
+
+// Manipulators returns the current locations of the manipulators relative to
+// the worker.
+func (w *Worker) Manipulators() []Point {
+	ps := make([]Point, len(w.manipulators))
+	for i, p := range w.manipulators {
+		ps[i] = rotate(p, w.rotation)
+	}
+	return ps
+}
+
+// canAttach checks whether a new manipulator can be attached at the given
+// location relative to the worker: it must be next to a side of the worker or
+// of another manipulator.
+func (w *Worker) canAttach(p Point) error {
+	ps := append([]Point{{}}, w.Manipulators()...)
+	adjacent := false
+	for _, d := range ps {
+		if d == p {
+			return fmt.Errorf("Cannot attach a manipulator at occupied %v.",
+				p)
+		}
+		adjacent = adjacent || iAbs(d.X-p.X)+iAbs(d.Y-p.Y) == 1
+	}
+	if !adjacent {
+		return fmt.Errorf("Cannot attach a manipulator at detached %v.", p)
+	}
+	return nil
+}
+
+// Attach attaches a new manipulator at the given location relative to the
+// worker. It must be next to a side of the worker or of another manipulator.
+func (w *Worker) Attach(p Point) error {
+	if err := w.canAttach(p); err != nil {
+		return err
+	}
+	w.manipulators = append(w.manipulators, rotate(p, -w.rotation))
+	return nil
+}
+
+// Reach returns the cells of the Map that the worker can wrap: the cell under
+// it and the cells under its manipulators that are visible from it.
+func (w *Worker) Reach(m Map) []Point {
+	var ps []Point
+	if m.isPassable(w.Loc) {
+		ps = append(ps, w.Loc)
+	}
+	for _, d := range w.Manipulators() {
+		p := Point{w.Loc.X + d.X, w.Loc.Y + d.Y}
+		if m.isPassable(p) && m.IsVisible(w.Loc, p) {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
@@ -0,0 +1,189 @@
+package wwabr
+
+import (
+	"sort"
+	"testing"
+)
+
+// newTestMap returns a rectangular Map of the given size with obstacles at
+// the given cells.
+func newTestMap(width, height int, obstacles ...Point) Map {
+	m := NewMap(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			m.Set(Point{x, y}, EmptyCell)
+		}
+	}
+	for _, p := range obstacles {
+		m.Set(p, ObstacleCell)
+	}
+	return m
+}
+
+func sortedPoints(ps []Point) []Point {
+	ps = append([]Point(nil), ps...)
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].X != ps[j].X {
+			return ps[i].X < ps[j].X
+		}
+		return ps[i].Y < ps[j].Y
+	})
+	return ps
+}
+
+func samePoints(a, b []Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = sortedPoints(a), sortedPoints(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestIsVisible(t *testing.T) {
+	tests := []struct {
+		obstacles []Point
+		from, to  Point
+		want      bool
+	}{
+		// Neighbouring cells.
+		{nil, Point{1, 1}, Point{2, 1}, true},
+		{[]Point{{2, 1}}, Point{1, 1}, Point{2, 1}, false},
+		// Touching the corners of obstacles is allowed.
+		{[]Point{{2, 1}}, Point{1, 1}, Point{2, 2}, true},
+		{[]Point{{2, 1}, {1, 2}}, Point{1, 1}, Point{2, 2}, true},
+		{[]Point{{1, 0}, {0, 1}}, Point{0, 0}, Point{1, 1}, true},
+		// Crossing the sides of obstacles is not.
+		{[]Point{{1, 1}}, Point{0, 0}, Point{1, 2}, false},
+		{[]Point{{0, 1}}, Point{0, 0}, Point{1, 2}, false},
+		{[]Point{{1, 0}}, Point{0, 0}, Point{1, 2}, true},
+		{[]Point{{2, 2}}, Point{0, 2}, Point{4, 2}, false},
+		{[]Point{{2, 3}}, Point{0, 2}, Point{4, 2}, true},
+		{[]Point{{2, 2}}, Point{0, 0}, Point{4, 4}, false},
+		{[]Point{{2, 1}, {1, 2}}, Point{0, 0}, Point{4, 4}, true},
+		{[]Point{{3, 1}}, Point{0, 0}, Point{4, 3}, true},
+		{[]Point{{3, 2}}, Point{0, 0}, Point{4, 3}, false},
+		{[]Point{{2, 1}}, Point{0, 0}, Point{4, 3}, false},
+	}
+	for _, tc := range tests {
+		m := newTestMap(5, 5, tc.obstacles...)
+		if got := m.IsVisible(tc.from, tc.to); got != tc.want {
+			t.Errorf("For %v to %v with obstacles %v, wanted %v, got %v.",
+				tc.from, tc.to, tc.obstacles, tc.want, got)
+		}
+	}
+}
+
+func TestIsVisibleOutsideMap(t *testing.T) {
+	// Walls block visibility like obstacles do.
+	m := newTestMap(3, 3)
+	m.Set(Point{1, 1}, InvalidCell)
+	if m.IsVisible(Point{0, 1}, Point{2, 1}) {
+		t.Errorf("Wanted (2,1) to be hidden from (0,1) by a wall.")
+	}
+}
+
+func TestTurn(t *testing.T) {
+	tests := []struct {
+		turns string
+		want  []Point
+	}{
+		{"", []Point{{1, 0}, {1, 1}, {1, -1}}},
+		{"E", []Point{{0, -1}, {1, -1}, {-1, -1}}},
+		{"Q", []Point{{0, 1}, {-1, 1}, {1, 1}}},
+		{"EE", []Point{{-1, 0}, {-1, -1}, {-1, 1}}},
+		{"EQ", []Point{{1, 0}, {1, 1}, {1, -1}}},
+		{"QQQQ", []Point{{1, 0}, {1, 1}, {1, -1}}},
+	}
+	for _, tc := range tests {
+		w := NewWorker(Point{5, 5})
+		for _, c := range tc.turns {
+			w.Turn(c == TurnCW)
+		}
+		if got := w.Manipulators(); !samePoints(got, tc.want) {
+			t.Errorf("For %q, wanted %v, got %v.", tc.turns, tc.want, got)
+		}
+	}
+}
+
+func TestAttach(t *testing.T) {
+	// The examples of Section 2.2.1 of the specification.
+	tests := []struct {
+		p    Point
+		want bool
+	}{
+		{Point{1, 2}, true},
+		{Point{-1, 0}, true},
+		{Point{2, 1}, true},
+		{Point{3, 1}, false},
+		{Point{0, 0}, false},
+		{Point{1, 1}, false},
+	}
+	for _, tc := range tests {
+		w := NewWorker(Point{5, 5})
+		if got := w.Attach(tc.p) == nil; got != tc.want {
+			t.Errorf("For %v, wanted %v, got %v.", tc.p, tc.want, got)
+		}
+	}
+
+	w := NewWorker(Point{5, 5})
+	for _, p := range []Point{{1, 2}, {-1, 0}} {
+		if err := w.Attach(p); err != nil {
+			t.Fatalf("For %v, got error %v.", p, err)
+		}
+	}
+	want := []Point{{4, 5}, {5, 5}, {6, 5}, {6, 6}, {6, 4}, {6, 7}}
+	if got := w.Reach(newTestMap(10, 10)); !samePoints(got, want) {
+		t.Errorf("Wanted reach %v, got %v.", want, got)
+	}
+}
+
+func TestAttachTurned(t *testing.T) {
+	// Manipulators are attached relative to the current orientation, and then
+	// turn with the others.
+	w := NewWorker(Point{5, 5})
+	w.Turn(true)
+	if err := w.Attach(Point{0, -2}); err != nil {
+		t.Fatalf("Got error %v.", err)
+	}
+	w.Turn(false)
+	want := []Point{{1, 0}, {1, 1}, {1, -1}, {2, 0}}
+	if got := w.Manipulators(); !samePoints(got, want) {
+		t.Errorf("Wanted %v, got %v.", want, got)
+	}
+}
+
+func TestReach(t *testing.T) {
+	// The reach of a worker with long arms is blocked by obstacles, as per
+	// Section 2.3 of the specification. An obstacle only blocks the cells
+	// whose centres cannot be seen past its sides.
+	w := NewWorker(Point{1, 2})
+	for _, p := range []Point{{1, 2}, {1, 3}, {1, -2}} {
+		if err := w.Attach(p); err != nil {
+			t.Fatalf("For %v, got error %v.", p, err)
+		}
+	}
+	tests := []struct {
+		obstacles []Point
+		want      []Point
+	}{
+		{nil, []Point{{1, 2}, {2, 2}, {2, 3}, {2, 1}, {2, 4}, {2, 5}, {2, 0}}},
+		{[]Point{{2, 3}}, []Point{{1, 2}, {2, 2}, {2, 1}, {2, 5}, {2, 0}}},
+		{[]Point{{1, 3}}, []Point{{1, 2}, {2, 2}, {2, 3}, {2, 1}, {2, 0}}},
+		{[]Point{{2, 2}}, []Point{{1, 2}, {2, 3}, {2, 1}, {2, 4}, {2, 5},
+			{2, 0}}},
+		{[]Point{{3, 3}}, []Point{{1, 2}, {2, 2}, {2, 3}, {2, 1}, {2, 4},
+			{2, 5}, {2, 0}}},
+	}
+	for _, tc := range tests {
+		got := w.Reach(newTestMap(6, 6, tc.obstacles...))
+		if !samePoints(got, tc.want) {
+			t.Errorf("For obstacles %v, wanted %v, got %v.", tc.obstacles,
+				tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"app/galaxy"
+)
+
+var addr = flag.String("addr", "localhost:12345",
+	"The address on which to serve the stand-in Alien Proxy Server.")
+
+var script = flag.String("script", "",
+	"A file of exchanges with which to answer matching requests.")
+
+var replay = flag.String("replay", "",
+	"A file of exchanges to be replayed in order, as written by -record.")
+
+var record = flag.String("record", "",
+	"A file into which to record the exchanges with the upstream server.")
+
+var upstream = flag.String("upstream", "https://api.pegovka.space/",
+	"The base URL for the Alien Proxy Server to use with -record.")
+
+var aKeyFile = flag.String("api_key_file", "",
+	"A file containing an API-key for the upstream Alien Proxy Server.")
+
+func readApiKey() string {
+	if *aKeyFile == "" {
+		return ""
+	}
+	akf, err := ioutil.ReadFile(*aKeyFile)
+	if err != nil {
+		log.Fatalf("Unable to read API-key from %q: %v", *aKeyFile, err)
+	}
+	return strings.TrimSpace(string(akf))
+}
+
+func readExchanges(f string) []*galaxy.Exchange {
+	xs, err := galaxy.ReadExchanges(f)
+	if err != nil {
+		log.Fatalf("Unable to load exchanges from %q: %v", f, err)
+	}
+	log.Printf("Found %d exchange(s) in %q.", len(xs), f)
+	return xs
+}
+
+func main() {
+	flag.Parse()
+	log.SetFlags(log.Ltime | log.Lshortfile)
+
+	p := &galaxy.AlienProxy{}
+	switch {
+	case *script != "" && *replay == "" && *record == "":
+		p.Exchanges = readExchanges(*script)
+	case *replay != "" && *script == "" && *record == "":
+		p.Exchanges = readExchanges(*replay)
+		p.Replay = true
+	case *record != "" && *script == "" && *replay == "":
+		f, err := os.Create(*record)
+		if err != nil {
+			log.Fatalf("Unable to create %q: %v", *record, err)
+		}
+		defer f.Close()
+		p.Upstream = *upstream
+		p.ApiKey = readApiKey()
+		p.Record = f
+	default:
+		log.Fatal("Need exactly one of -script, -replay or -record.")
+	}
+
+	log.Printf("Serving the Alien Proxy Server at %q.", *addr)
+	if err := http.ListenAndServe(*addr, p); err != nil {
+		log.Fatalf("Unable to serve at %q: %v", *addr, err)
+	}
+}
@@ -90,6 +90,15 @@ func (v *vect) String() string {
 	return fmt.Sprintf("(%d, %d)", v.x, v.y)
 }
 
+// apNotation returns the expression in the notation of the
+// interaction-protocol, e.g. "ap ap cons 1 nil".
+func apNotation(e expr) string {
+	if a, ok := e.(*ap); ok {
+		return fmt.Sprintf("ap %s %s", apNotation(a.fun), apNotation(a.arg))
+	}
+	return fmt.Sprintf("%v", e)
+}
+
 func mkNil() expr {
 	return &atom{exp: nil, aType: atNil}
 }
@@ -185,6 +185,9 @@ func encodeMsg(e expr) (string, error) {
 }
 
 func decodeMsg(r []rune) (expr, error) {
+	if len(r) < 2 {
+		return nil, fmt.Errorf("too few runes (%d) to decode", len(r))
+	}
 	if r[0] == '1' && r[1] == '1' {
 		e, _, err := demodulateList(r)
 		return e, err
@@ -231,6 +234,10 @@ func sendToAliens(ctx *InterCtx, e expr) (expr, error) {
 	if err != nil {
 		return nil, err
 	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("aliens responded with %q: %s", res.Status,
+			strings.TrimSpace(string(body)))
+	}
 
 	var r expr
 	if r, err = decodeMsg([]rune(string(body))); err == nil {
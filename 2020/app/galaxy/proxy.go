@@ -0,0 +1,171 @@
+package galaxy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// The request of an exchange in a script that matches any request.
+const anyRequest = "*"
+
+// Exchange is a request sent to the aliens and their response.
+type Exchange struct {
+	Request  expr
+	Response expr
+}
+
+func (x *Exchange) String() string {
+	req := anyRequest
+	if x.Request != nil {
+		req = apNotation(x.Request)
+	}
+	return fmt.Sprintf("%s = %s", req, apNotation(x.Response))
+}
+
+// ReadExchanges reads exchanges from a file, one per line as "<request> =
+// <response>" with both in the notation of the interaction-protocol. A request
+// of "*" matches any request. Blank lines and lines starting with "#" are
+// skipped.
+func ReadExchanges(f string) ([]*Exchange, error) {
+	file, err := os.Open(f)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var xs []*Exchange
+	scanner := bufio.NewScanner(file)
+	for ln := 1; scanner.Scan(); ln++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var x *Exchange
+		if x, err = parseExchange(line); err != nil {
+			return nil, fmt.Errorf("line #%d: %v", ln, err)
+		}
+		xs = append(xs, x)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return xs, nil
+}
+
+func parseExchange(s string) (*Exchange, error) {
+	rr := strings.SplitN(s, " = ", 2)
+	if len(rr) != 2 {
+		return nil, fmt.Errorf("expected \"<request> = <response>\"")
+	}
+	x := &Exchange{}
+	var err error
+	if strings.TrimSpace(rr[0]) != anyRequest {
+		if x.Request, err = strToExpr(rr[0]); err != nil {
+			return nil, fmt.Errorf("error parsing request: %v", err)
+		}
+	}
+	if x.Response, err = strToExpr(rr[1]); err != nil {
+		return nil, fmt.Errorf("error parsing response: %v", err)
+	}
+	return x, nil
+}
+
+// AlienProxy is a local stand-in for the Alien Proxy Server. It answers
+// the messages sent to "/aliens/send" in one of three ways:
+//   - scripted: with the response of the first of the Exchanges whose request
+//     matches the message;
+//   - replayed: with the responses of the Exchanges in order, provided that
+//     each message matches the request of the next Exchange;
+//   - recorded: with the response of the Alien Proxy Server at Upstream,
+//     writing each exchange to Record.
+type AlienProxy struct {
+	Exchanges []*Exchange
+	Replay    bool
+
+	Upstream string
+	ApiKey   string
+	Record   io.Writer
+
+	mu   sync.Mutex
+	next int
+}
+
+func (p *AlienProxy) respond(req expr) (expr, int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Upstream != "" {
+		ctx := &InterCtx{BaseUrl: p.Upstream, ApiKey: p.ApiKey}
+		res, err := sendToAliens(ctx, req)
+		if err != nil {
+			return nil, http.StatusBadGateway, err
+		}
+		x := &Exchange{Request: req, Response: res}
+		if p.Record != nil {
+			if _, err = fmt.Fprintln(p.Record, x); err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+		}
+		return res, http.StatusOK, nil
+	}
+	if p.Replay {
+		if p.next >= len(p.Exchanges) {
+			return nil, http.StatusNotFound, fmt.Errorf(
+				"no exchange left for request #%d %v", p.next, req)
+		}
+		x := p.Exchanges[p.next]
+		if x.Request != nil && !eqExprs(x.Request, req) {
+			return nil, http.StatusConflict, fmt.Errorf(
+				"request #%d is %v instead of %v", p.next, req, x.Request)
+		}
+		p.next++
+		return x.Response, http.StatusOK, nil
+	}
+	for _, x := range p.Exchanges {
+		if x.Request == nil || eqExprs(x.Request, req) {
+			return x.Response, http.StatusOK, nil
+		}
+	}
+	return nil, http.StatusNotFound, fmt.Errorf("no exchange for %v", req)
+}
+
+func (p *AlienProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/aliens/send" {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req, res expr
+	msg := []rune(strings.TrimSpace(string(body)))
+	if req, err = decodeMsg(msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("Received: %q", req)
+
+	var code int
+	if res, code, err = p.respond(req); err != nil {
+		log.Printf("Error responding to %q: %v", req, err)
+		http.Error(w, err.Error(), code)
+		return
+	}
+	var enc string
+	if enc, err = encodeMsg(res); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Responding: %q", res)
+	w.Header().Set("Content-Type", "text/plain")
+	io.WriteString(w, enc)
+}
@@ -0,0 +1,169 @@
+package galaxy
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func mustParseExchanges(t *testing.T, ss ...string) []*Exchange {
+	xs := make([]*Exchange, 0, len(ss))
+	for _, s := range ss {
+		x, err := parseExchange(s)
+		if err != nil {
+			t.Fatalf("Error parsing exchange %q: %v", s, err)
+		}
+		xs = append(xs, x)
+	}
+	return xs
+}
+
+// checkSends sends each request to the proxy in turn and checks the response,
+// or that there is an error if the wanted response is empty.
+func checkSends(t *testing.T, p *AlienProxy, tests []struct{ req, want string }) {
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+	ctx := &InterCtx{BaseUrl: srv.URL}
+	for i, tc := range tests {
+		req, err := strToExpr(tc.req)
+		if err != nil {
+			t.Fatalf("Error converting %q to expr %v.", tc.req, err)
+		}
+		got, gErr := sendToAliens(ctx, req)
+		if tc.want == "" {
+			if gErr == nil {
+				t.Errorf("#%d: For %q, wanted an error, got %q.", i, tc.req,
+					got)
+			}
+			continue
+		}
+		if gErr != nil {
+			t.Errorf("#%d: For %q, got error %v.", i, tc.req, gErr)
+			continue
+		}
+		we, _ := strToExpr(tc.want)
+		if !eqExprs(got, we) {
+			t.Errorf("#%d: For %q, wanted %q, got %q.", i, tc.req, we, got)
+		}
+	}
+}
+
+func TestAlienProxyScript(t *testing.T) {
+	p := &AlienProxy{Exchanges: mustParseExchanges(t,
+		"0 = ap ap cons 1 nil",
+		"ap ap cons 2 ap ap cons 3 nil = ap ap cons 0 ap ap cons 5 nil",
+		"ap ap cons 2 nil = -7",
+	)}
+	checkSends(t, p, []struct{ req, want string }{
+		{"0", "ap ap cons 1 nil"},
+		{"ap ap cons 2 ap ap cons 3 nil", "ap ap cons 0 ap ap cons 5 nil"},
+		{"0", "ap ap cons 1 nil"},
+		{"ap ap cons 2 nil", "-7"},
+		{"1", ""},
+	})
+
+	// A request of "*" matches anything not matched by an earlier exchange.
+	p.Exchanges = append(p.Exchanges, mustParseExchanges(t, "* = nil")...)
+	checkSends(t, p, []struct{ req, want string }{
+		{"0", "ap ap cons 1 nil"},
+		{"1", "nil"},
+		{"ap ap cons 1 nil", "nil"},
+	})
+}
+
+func TestAlienProxyReplay(t *testing.T) {
+	p := &AlienProxy{Replay: true, Exchanges: mustParseExchanges(t,
+		"0 = 1",
+		"0 = 2",
+		"* = 3",
+	)}
+	checkSends(t, p, []struct{ req, want string }{
+		{"0", "1"},
+		// Out of order.
+		{"5", ""},
+		{"0", "2"},
+		{"5", "3"},
+		// Nothing left.
+		{"0", ""},
+	})
+}
+
+func TestAlienProxyRecord(t *testing.T) {
+	up := &AlienProxy{Exchanges: mustParseExchanges(t,
+		"0 = ap ap cons 1 nil",
+		"ap ap cons 1 ap ap cons -2 nil = ap ap cons ap ap cons 3 4 nil",
+	)}
+	upSrv := httptest.NewServer(up)
+	defer upSrv.Close()
+
+	var rec bytes.Buffer
+	tests := []struct{ req, want string }{
+		{"ap ap cons 1 ap ap cons -2 nil", "ap ap cons ap ap cons 3 4 nil"},
+		{"0", "ap ap cons 1 nil"},
+		{"0", "ap ap cons 1 nil"},
+	}
+	checkSends(t, &AlienProxy{Upstream: upSrv.URL, Record: &rec}, tests)
+
+	// The recording replays the same exchanges.
+	lines := strings.Split(strings.TrimSpace(rec.String()), "\n")
+	if len(lines) != len(tests) {
+		t.Fatalf("Wanted %d recorded exchanges, got %q.", len(tests), lines)
+	}
+	p := &AlienProxy{Replay: true, Exchanges: mustParseExchanges(t, lines...)}
+	checkSends(t, p, tests)
+}
+
+func TestInteractOffline(t *testing.T) {
+	// A protocol ignoring its state that sends the event to the aliens if its
+	// first element is 0, and otherwise returns it as the new state and data.
+	defs := []string{
+		"ip = ap t :g",
+		":g = ap ap s ap ap b cons :flag :rest",
+		":flag = ap ap c ap ap c ap ap b ap eq 0 car 1 0",
+		":rest = ap ap s ap ap b cons i ap ap c ap ap b cons i nil",
+	}
+	fds := &FuncDefs{ip: "ip", fds: make(map[string]expr)}
+	for _, d := range defs {
+		fd, err := parseFuncDef([]byte(d))
+		if err != nil {
+			t.Fatalf("Error parsing %q: %v", d, err)
+		}
+		fds.fds[fd.name] = fd.def
+	}
+	p := &AlienProxy{Exchanges: mustParseExchanges(t,
+		"ap ap cons 0 5 = ap ap cons 7 ap ap cons 8 nil",
+	)}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+	ctx := &InterCtx{BaseUrl: srv.URL, Protocol: fds}
+
+	tests := []struct {
+		event   expr
+		want    string
+		wantErr bool
+	}{
+		{vec2e(&vect{x: 3, y: 5}), "ap ap cons 3 5", false},
+		{vec2e(&vect{x: 0, y: 5}), "ap ap cons 7 ap ap cons 8 nil", false},
+		// No scripted response.
+		{vec2e(&vect{x: 0, y: 6}), "", true},
+	}
+	for _, tc := range tests {
+		st, data, err := interact(ctx, mkNil(), tc.event)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("For %v, wanted an error, got %v.", tc.event, data)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("For %v, got error %v.", tc.event, err)
+			continue
+		}
+		we, _ := strToExpr(tc.want)
+		if !eqExprs(st, we) || !eqExprs(data, we) {
+			t.Errorf("For %v, wanted %q, got state %q and data %q.", tc.event,
+				we, st, data)
+		}
+	}
+}